@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// readAllowedSerials reads the serial numbers in the file, one per line, in
+// any of the forms accepted by parseSerialNumber. Blank lines and lines
+// starting with '#' are ignored.
+func readAllowedSerials(path string) ([]*big.Int, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadAllowedSerials
+	}
+
+	var serials []*big.Int
+
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		serial, err := parseSerialNumber(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		serials = append(serials, serial)
+	}
+
+	return serials, nil
+}
+
+// checkAllowedSerial returns an error unless the serial number of the
+// certificate is one of the allowed serial numbers.
+func checkAllowedSerial(cert *x509.Certificate, serials []*big.Int) error {
+	for _, serial := range serials {
+		if serial.Cmp(cert.SerialNumber) == 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %s (0x%X)", errSerialNotAllowed, cert.SerialNumber, cert.SerialNumber)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCheckAllowedSerial(t *testing.T) {
+	serials, err := readAllowedSerials("./testdata/allowed_serials.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(serials) != 2 {
+		t.Fatalf("expected 2 serials, got %d", len(serials))
+	}
+
+	for _, path := range []string{"./testdata/twitter.pem", "./testdata/cisco_revoked.pem"} {
+		cert, _ := readCertificate(path)
+		if err := checkAllowedSerial(cert, serials); err != nil {
+			t.Errorf("%s: expected no error, got %q", path, err)
+		}
+	}
+}
+
+func TestCheckAllowedSerialNotAllowed(t *testing.T) {
+	serials, _ := readAllowedSerials("./testdata/allowed_serials.txt")
+	cert, _ := readCertificate("./testdata/certificate.pem")
+
+	err := checkAllowedSerial(cert, serials)
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := "serial number not allowed: 11688423725690511159837931372751711178 (0x8CB1BC8556C04D93BBD6949ED816BCA)"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+)
+
+// asn1TagNames maps the universal ASN.1 tags used in OCSP responses to their
+// names.
+var asn1TagNames = map[int]string{
+	asn1.TagBoolean:         "BOOLEAN",
+	asn1.TagInteger:         "INTEGER",
+	asn1.TagBitString:       "BIT STRING",
+	asn1.TagOctetString:     "OCTET STRING",
+	asn1.TagNull:            "NULL",
+	asn1.TagOID:             "OBJECT IDENTIFIER",
+	asn1.TagEnum:            "ENUMERATED",
+	asn1.TagUTF8String:      "UTF8String",
+	asn1.TagSequence:        "SEQUENCE",
+	asn1.TagSet:             "SET",
+	asn1.TagNumericString:   "NumericString",
+	asn1.TagPrintableString: "PrintableString",
+	asn1.TagT61String:       "T61String",
+	asn1.TagIA5String:       "IA5String",
+	asn1.TagUTCTime:         "UTCTime",
+	asn1.TagGeneralizedTime: "GeneralizedTime",
+	asn1.TagGeneralString:   "GeneralString",
+	asn1.TagBMPString:       "BMPString",
+}
+
+// maxDumpBytes is the number of bytes of a binary value that are printed,
+// before it is truncated.
+const maxDumpBytes = 32
+
+// dumpASN1 writes the DER encoded data as an indented tree of ASN.1
+// elements, with their tag, length and value. Octet strings that hold DER
+// themselves, such as the basic OCSP response, are expanded.
+func dumpASN1(w io.Writer, data []byte) error {
+	return dumpASN1Elements(w, data, 0)
+}
+
+func dumpASN1Elements(w io.Writer, data []byte, depth int) error {
+	for len(data) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(data, &v)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s%s (%d bytes)", strings.Repeat("  ", depth), asn1TagName(v), len(v.Bytes))
+
+		switch {
+		case v.IsCompound:
+			fmt.Fprintln(w)
+			if err := dumpASN1Elements(w, v.Bytes, depth+1); err != nil {
+				return err
+			}
+		case v.Class == asn1.ClassUniversal && v.Tag == asn1.TagOctetString && isDER(v.Bytes):
+			fmt.Fprintln(w)
+			if err := dumpASN1Elements(w, v.Bytes, depth+1); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintf(w, ": %s\n", asn1Value(v))
+		}
+
+		data = rest
+	}
+
+	return nil
+}
+
+// asn1TagName returns the name of the element's tag, with context-specific
+// tags in brackets, e.g. '[0]'.
+func asn1TagName(v asn1.RawValue) string {
+	switch v.Class {
+	case asn1.ClassUniversal:
+		if name, ok := asn1TagNames[v.Tag]; ok {
+			return name
+		}
+		return fmt.Sprintf("UNIVERSAL %d", v.Tag)
+	case asn1.ClassContextSpecific:
+		return fmt.Sprintf("[%d]", v.Tag)
+	case asn1.ClassApplication:
+		return fmt.Sprintf("[APPLICATION %d]", v.Tag)
+	default:
+		return fmt.Sprintf("[PRIVATE %d]", v.Tag)
+	}
+}
+
+// asn1Value returns a readable representation of the value of the primitive
+// element, falling back to hex for types it does not decode.
+func asn1Value(v asn1.RawValue) string {
+	if v.Class == asn1.ClassUniversal {
+		switch v.Tag {
+		case asn1.TagNull:
+			return "NULL"
+		case asn1.TagEnum:
+			var e asn1.Enumerated
+			if _, err := asn1.Unmarshal(v.FullBytes, &e); err == nil {
+				return fmt.Sprint(int(e))
+			}
+		case asn1.TagInteger:
+			var i *big.Int
+			if _, err := asn1.Unmarshal(v.FullBytes, &i); err == nil {
+				return i.String()
+			}
+		case asn1.TagBoolean, asn1.TagOID, asn1.TagUTCTime, asn1.TagGeneralizedTime:
+			var value interface{}
+			if _, err := asn1.Unmarshal(v.FullBytes, &value); err == nil {
+				return fmt.Sprint(value)
+			}
+		case asn1.TagUTF8String, asn1.TagPrintableString, asn1.TagIA5String,
+			asn1.TagNumericString, asn1.TagT61String:
+			return fmt.Sprintf("%q", v.Bytes)
+		}
+	}
+
+	if len(v.Bytes) > maxDumpBytes {
+		return hex.EncodeToString(v.Bytes[:maxDumpBytes]) + "..."
+	}
+	return hex.EncodeToString(v.Bytes)
+}
+
+// isDER reports whether the data consists entirely of a single constructed
+// DER element.
+func isDER(data []byte) bool {
+	var v asn1.RawValue
+	rest, err := asn1.Unmarshal(data, &v)
+	return err == nil && len(rest) == 0 && v.IsCompound
+}
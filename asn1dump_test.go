@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDumpASN1(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
+
+	buf := new(bytes.Buffer)
+	if err := dumpASN1(buf, rawResp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []string{
+		"SEQUENCE (",
+		"  ENUMERATED (1 bytes): 0\n",
+		"    OBJECT IDENTIFIER (9 bytes): 1.3.6.1.5.5.7.48.1.1\n",      // id-pkix-ocsp-basic
+		"INTEGER (16 bytes): 16190166165489431910151563605275097819\n", // serial number
+		"GeneralizedTime (15 bytes): 2017-12-30 05:45:33 +0000 UTC\n",
+	} {
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected %q in dump, got %q", expected, buf.String())
+		}
+	}
+}
+
+func TestDumpASN1Invalid(t *testing.T) {
+	err := dumpASN1(new(bytes.Buffer), []byte("not DER"))
+	if err == nil {
+		t.Fatal("should return error")
+	}
+}
@@ -1,22 +1,49 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ocsp"
 	"io/ioutil"
 	"math/big"
+	"net/http"
 )
 
-func getCRLDistributionPoint(cert *x509.Certificate) (string, error) {
+var oidExtensionReasonCode = asn1.ObjectIdentifier{2, 5, 29, 21}
+
+// tbsCertListIssuer is the start of the TBSCertList of a CRL (RFC 5280,
+// section 5.1), up to the issuer, which is kept in its DER encoding.
+type tbsCertListIssuer struct {
+	Version   int `asn1:"optional,default:0"`
+	Signature pkix.AlgorithmIdentifier
+	Issuer    asn1.RawValue
+}
+
+func getCRLDistributionPoints(cert *x509.Certificate) ([]string, error) {
 	points := cert.CRLDistributionPoints
 	if len(points) == 0 {
-		return "", errNoCRLDistributionPointsFound
+		return nil, errNoCRLDistributionPointsFound
+	}
+
+	points = secureEndpoints(points)
+	if len(points) == 0 {
+		return nil, errNoHTTPSEndpoints
 	}
-	return points[0], nil
+	return points, nil
 }
 
-func getCRL(url string) (*pkix.CertificateList, error) {
-	resp, err := client.Get(url)
+func getCRL(ctx context.Context, client HTTPClient, url string) (*pkix.CertificateList, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
 	if err != nil {
 		return nil, err
 	}
@@ -27,6 +54,10 @@ func getCRL(url string) (*pkix.CertificateList, error) {
 		}
 	}()
 
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errFailedToGetResource, resp.Status)
+	}
+
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		return nil, err
@@ -36,6 +67,32 @@ func getCRL(url string) (*pkix.CertificateList, error) {
 	return x509.ParseCRL(body)
 }
 
+// verifyCRL checks that the CRL covers the certificate, i.e. that it was
+// issued by the certificate's issuer. When issuer is nil, only the issuer
+// names are compared, and the signature is not verified.
+func verifyCRL(crlList *pkix.CertificateList, cert *x509.Certificate, issuer *x509.Certificate) error {
+	// NOTE: the DER encoded names are compared, as distinct names can print
+	// the same, e.g. when they differ only in attributes that are not printed
+	var tbs tbsCertListIssuer
+	if _, err := asn1.Unmarshal(crlList.TBSCertList.Raw, &tbs); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(tbs.Issuer.FullBytes, cert.RawIssuer) {
+		return errCRLIssuerMismatch
+	}
+
+	if issuer == nil {
+		return nil
+	}
+
+	if err := issuer.CheckCRLSignature(crlList); err != nil {
+		return fmt.Errorf("%w: %v", errInvalidCRLSignature, err)
+	}
+
+	return nil
+}
+
 func findCert(serialNumber *big.Int, crlList *pkix.CertificateList) *pkix.RevokedCertificate {
 	for revoked := range crlList.TBSCertList.RevokedCertificates {
 		revCert := crlList.TBSCertList.RevokedCertificates[revoked]
@@ -48,32 +105,102 @@ func findCert(serialNumber *big.Int, crlList *pkix.CertificateList) *pkix.Revoke
 	return nil
 }
 
-// GetCRLResponse returns the CRL status for the specified certificate.
-func GetCRLResponse(client HTTPClient, cert *x509.Certificate) (*Status, error) {
-	endpoint, err := getCRLDistributionPoint(cert)
+// reasonCode returns the reason code of the revoked certificate entry, which
+// is unspecified when the entry does not carry a reason code extension.
+func reasonCode(revCert *pkix.RevokedCertificate) int {
+	for _, ext := range revCert.Extensions {
+		if !ext.Id.Equal(oidExtensionReasonCode) {
+			continue
+		}
+
+		var reason asn1.Enumerated
+		if _, err := asn1.Unmarshal(ext.Value, &reason); err == nil {
+			return int(reason)
+		}
+	}
+
+	return ocsp.Unspecified
+}
+
+// getVerifiedCRL fetches the CRLs from all distribution points concurrently,
+// and returns the first one that covers the certificate, cancelling the other
+// requests. When none does, the returned error wraps errNoValidCRL along with
+// the failure for each distribution point.
+func getVerifiedCRL(client HTTPClient, endpoints []string, cert *x509.Certificate, issuer *x509.Certificate) (*pkix.CertificateList, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type result struct {
+		endpoint string
+		crlList  *pkix.CertificateList
+		err      error
+	}
+
+	results := make(chan result, len(endpoints)) // buffered, so no worker blocks
+	for _, endpoint := range endpoints {
+		go func(endpoint string) {
+			crlList, err := getCRL(ctx, client, endpoint)
+			if err == nil {
+				err = verifyCRL(crlList, cert, issuer)
+			}
+			results <- result{endpoint, crlList, err}
+		}(endpoint)
+	}
+
+	errs := []error{errNoValidCRL}
+
+	for range endpoints {
+		r := <-results
+		if r.err != nil {
+			explain("failed to get CRL from %s: %v", r.endpoint, r.err)
+			errs = append(errs, fmt.Errorf("%s: %w", r.endpoint, r.err))
+			continue
+		}
+
+		explain("using CRL from %s", r.endpoint)
+		return r.crlList, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// GetCRLResponse returns the CRL status for the specified certificate. The
+// CRL signature is verified against issuer, unless issuer is nil.
+func GetCRLResponse(client HTTPClient, cert *x509.Certificate, issuer *x509.Certificate) (*Status, error) {
+	endpoints, err := getCRLDistributionPoints(cert)
 	if err != nil {
 		return nil, err
 	}
+	explain("found CRL distribution points %v", endpoints)
 
-	crlList, err := getCRL(endpoint)
-
+	crlList, err := getVerifiedCRL(client, endpoints, cert, issuer)
 	if err != nil {
-		// TODO: return proper error, e.g. 'could not get crl'
 		return nil, err
 	}
+	explain("fetched CRL with %d entries, this update %s, next update %s",
+		len(crlList.TBSCertList.RevokedCertificates), crlList.TBSCertList.ThisUpdate, crlList.TBSCertList.NextUpdate)
 
 	revCert := findCert(cert.SerialNumber, crlList)
 
 	if revCert != nil {
+		explain("serial number %s is listed on the CRL", cert.SerialNumber)
+		code := reasonCode(revCert)
 		return &Status{
 			SerialNumber: cert.SerialNumber,
 			Status:       "Revoked",
+			Reason:       revocationReason(code),
+			ReasonCode:   code,
 			RevokedAt:    revCert.RevocationTime,
+			ThisUpdate:   crlList.TBSCertList.ThisUpdate,
+			NextUpdate:   crlList.TBSCertList.NextUpdate,
 		}, nil
 	}
 
+	explain("serial number %s is not listed on the CRL", cert.SerialNumber)
 	return &Status{
 		SerialNumber: cert.SerialNumber,
 		Status:       "Good",
+		ThisUpdate:   crlList.TBSCertList.ThisUpdate,
+		NextUpdate:   crlList.TBSCertList.NextUpdate,
 	}, nil
 }
@@ -1,31 +1,38 @@
 package main
 
 import (
+	"context"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
 	"io/ioutil"
 	"math/big"
+	"reflect"
+	"strings"
 	"testing"
 )
 
 func TestGetDistributionPoint(t *testing.T) {
 	cert, _ := readCertificate("./testdata/certificate.pem")
-	server, _ := getCRLDistributionPoint(cert)
+	servers, _ := getCRLDistributionPoints(cert)
 
-	expected := "http://crl3.digicert.com/ssca-sha2-g3.crl"
+	expected := []string{
+		"http://crl3.digicert.com/ssca-sha2-g3.crl",
+		"http://crl4.digicert.com/ssca-sha2-g3.crl",
+	}
 
-	if server != expected {
-		t.Errorf("expected %q, got %q", expected, server)
+	if !reflect.DeepEqual(servers, expected) {
+		t.Errorf("expected %q, got %q", expected, servers)
 	}
 }
 
 func TestGetDestributionPointFromCertWithoutCRL(t *testing.T) {
 	cert, _ := readCertificate("./testdata/cloudflare_origin_ca_rsa_root.crt")
-	server, _ := getCRLDistributionPoint(cert)
-
-	expected := ""
+	servers, err := getCRLDistributionPoints(cert)
 
-	if server != expected {
-		t.Errorf("expected %q, got %q", expected, server)
+	if len(servers) != 0 || err != errNoCRLDistributionPointsFound {
+		t.Errorf("expected %q, got %q", errNoCRLDistributionPointsFound, err)
 	}
 }
 
@@ -71,7 +78,8 @@ func TestGetCRLResponse(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	st, err := GetCRLResponse(client, cert)
+	// NOTE: the issuer is not available, so the signature is not verified
+	st, err := GetCRLResponse(client, cert, nil)
 
 	if err != nil {
 		t.Fatal(err)
@@ -81,6 +89,11 @@ func TestGetCRLResponse(t *testing.T) {
 	if st.Status != expected {
 		t.Errorf("expected %q, got %q", expected, st.Status)
 	}
+
+	expected = "Key compromise"
+	if st.Reason != expected {
+		t.Errorf("expected %q, got %q", expected, st.Reason)
+	}
 }
 
 func TestGetCRLResponseNotRevoked(t *testing.T) {
@@ -91,7 +104,12 @@ func TestGetCRLResponseNotRevoked(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	st, err := GetCRLResponse(client, cert)
+	issuer, err := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := GetCRLResponse(client, cert, issuer)
 
 	if err != nil {
 		t.Fatal(err)
@@ -102,3 +120,153 @@ func TestGetCRLResponseNotRevoked(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, st.Status)
 	}
 }
+
+func TestGetCRLResponseEmptyCRL(t *testing.T) {
+	client = &MockHTTPClient{}
+	cert, err := readCertificate("./testdata/empty_crl_leaf.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, err := readCertificate("./testdata/test_ca.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := GetCRLResponse(client, cert, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Serial number: 219\n\n" +
+		"Status: Good\n\n" +
+		"This update: 2018-01-01 00:00:00 +0000 UTC\n" +
+		"Next update: 2038-01-01 00:00:00 +0000 UTC\n"
+
+	got := st.String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestGetDistributionPointRequireHTTPS(t *testing.T) {
+	*reqHTTPS = true
+	defer func() { *reqHTTPS = false }()
+
+	cert, _ := readCertificate("./testdata/certificate.pem")
+	_, err := getCRLDistributionPoints(cert)
+	if err != errNoHTTPSEndpoints {
+		t.Errorf("expected %q, got %q", errNoHTTPSEndpoints, err)
+	}
+}
+
+func TestGetCRLResponseFirstValidCRL(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer, err := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: only the last distribution point serves a CRL by this issuer
+	cert.CRLDistributionPoints = []string{
+		"http://crl.example.com/missing.crl",
+		"http://crl.example.com/empty.crl",
+		"http://crl.example.com/sha2-ev-server-g2.crl",
+	}
+
+	client := &MockHTTPClient{}
+	st, err := GetCRLResponse(client, cert, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Good"
+	if st.Status != expected {
+		t.Errorf("expected %q, got %q", expected, st.Status)
+	}
+}
+
+func TestGetCRLResponseNoValidCRL(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: the CRL is valid, but not signed by this issuer
+	issuer, err := readCertificate("./testdata/DigiCertSHA2SecureServerCA.crt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert.CRLDistributionPoints = []string{
+		"http://crl.example.com/empty.crl",
+		"http://crl.example.com/sha2-ev-server-g2.crl",
+	}
+
+	client := &MockHTTPClient{}
+	_, err = GetCRLResponse(client, cert, issuer)
+	if !errors.Is(err, errNoValidCRL) {
+		t.Fatalf("expected %q, got %q", errNoValidCRL, err)
+	}
+
+	if !errors.Is(err, errCRLIssuerMismatch) || !errors.Is(err, errInvalidCRLSignature) {
+		t.Errorf("expected issuer mismatch and invalid signature, got %q", err)
+	}
+
+	for _, endpoint := range cert.CRLDistributionPoints {
+		if !strings.Contains(err.Error(), endpoint+": ") {
+			t.Errorf("expected failure for %s, got %q", endpoint, err)
+		}
+	}
+}
+
+func TestGetCRLNotFound(t *testing.T) {
+	client := &MockHTTPClient{}
+	_, err := getCRL(context.Background(), client, "http://crl.example.com/missing.crl")
+	if !errors.Is(err, errFailedToGetResource) {
+		t.Fatalf("expected %q, got %v", errFailedToGetResource, err)
+	}
+
+	expected := "failed to get resource: 404 Not Found"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestVerifyCRLIssuerMismatch(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	rawCRL, _ := ioutil.ReadFile("./testdata/sha2-ev-server-g2.crl")
+	crlList, err := x509.ParseCRL(rawCRL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyCRL(crlList, cert, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// NOTE: the same name, encoded as UTF8String instead of PrintableString,
+	// prints the same but is not the same issuer name
+	var issuer pkix.RDNSequence
+	if _, err := asn1.Unmarshal(cert.RawIssuer, &issuer); err != nil {
+		t.Fatal(err)
+	}
+	for _, rdn := range issuer {
+		for i := range rdn {
+			rdn[i].Value = asn1.RawValue{Tag: asn1.TagUTF8String, Bytes: []byte(rdn[i].Value.(string))}
+		}
+	}
+	cert.RawIssuer, err = asn1.Marshal(issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verifyCRL(crlList, cert, nil); err != errCRLIssuerMismatch {
+		t.Errorf("expected %q, got %v", errCRLIssuerMismatch, err)
+	}
+}
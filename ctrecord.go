@@ -0,0 +1,32 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+)
+
+// ctRecord is the compact record printed with -ct-record, holding only the
+// fields that certificate transparency monitors need.
+type ctRecord struct {
+	SerialNumber string `json:"serial"`
+	Issuer       string `json:"issuer"`
+	NotBefore    string `json:"not_before"`
+	NotAfter     string `json:"not_after"`
+	Status       string `json:"status"`
+}
+
+// ctRecordLine returns the status of the certificate as a single line of
+// compact JSON.
+func ctRecordLine(st *Status, cert *x509.Certificate) (string, error) {
+	data, err := json.Marshal(ctRecord{
+		SerialNumber: st.SerialNumber.String(),
+		Issuer:       cert.Issuer.String(),
+		NotBefore:    formatTime(cert.NotBefore),
+		NotAfter:     formatTime(cert.NotAfter),
+		Status:       fields["status"](st),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
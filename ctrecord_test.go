@@ -0,0 +1,25 @@
+package main
+
+import (
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"testing"
+)
+
+func TestCTRecordLine(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+	cert, _ := readCertificate("./testdata/cisco_revoked.pem")
+
+	got, err := ctRecordLine(statusFromResponse(resp), cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"serial":"582831098329266023459877175593458587837818271346",` +
+		`"issuer":"CN=HydrantID SSL ICA G2,O=HydrantID (Avalanche Cloud Corporation),C=US",` +
+		`"not_before":"2016-11-16T11:56:51Z","not_after":"2018-11-16T11:56:46Z","status":"revoked"}`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
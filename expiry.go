@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+	"math"
+	"time"
+)
+
+// soonestExpiry returns the certificate in the chain that expires first, or
+// nil if none of them carries an expiry time.
+func soonestExpiry(chain []*x509.Certificate) *x509.Certificate {
+	var soonest *x509.Certificate
+
+	for _, cert := range chain {
+		if cert == nil || cert.NotAfter.IsZero() {
+			continue
+		}
+
+		if soonest == nil || cert.NotAfter.Before(soonest.NotAfter) {
+			soonest = cert
+		}
+	}
+
+	return soonest
+}
+
+// issuerChain returns the certificate and its issuer, followed by the
+// certificates in the chain that issued the issuer in turn, up to the root.
+// Certificates in the chain that are not on this path, such as unrelated
+// certificates in a bundle, are left out.
+func issuerChain(cert, issuer *x509.Certificate, chain []*x509.Certificate) []*x509.Certificate {
+	path := []*x509.Certificate{cert}
+
+	// NOTE: the length is bounded, in case cross-signed certificates in the
+	// chain issued each other
+	for next := issuer; next != nil && len(path) <= len(chain)+1; next = findIssuer(next, chain) {
+		path = append(path, next)
+		if bytes.Equal(next.RawSubject, next.RawIssuer) {
+			break // self-signed
+		}
+	}
+
+	return path
+}
+
+// daysRemaining returns the number of whole days until the certificate
+// expires, which is negative once it has expired.
+func daysRemaining(cert *x509.Certificate, now time.Time) int {
+	return int(cert.NotAfter.Sub(now).Hours() / 24)
+}
+
+// expiryMessage describes when the certificate expires, relative to now.
+func expiryMessage(cert *x509.Certificate, now time.Time) string {
+	days := daysRemaining(cert, now)
+
+	if cert.NotAfter.Before(now) {
+		return fmt.Sprintf("%s, expired at %s (%d days ago)", cert.Subject.CommonName, cert.NotAfter, -days)
+	}
+	return fmt.Sprintf("%s, expires at %s (%d days remaining)", cert.Subject.CommonName, cert.NotAfter, days)
+}
+
+// validityElapsed returns the percentage of the certificate's validity period
+// that has elapsed at now, clamped to 0 before and 100 after it.
+func validityElapsed(cert *x509.Certificate, now time.Time) float64 {
+	total := cert.NotAfter.Sub(cert.NotBefore)
+	if total <= 0 {
+		if now.Before(cert.NotAfter) {
+			return 0
+		}
+		return 100
+	}
+
+	elapsed := 100 * float64(now.Sub(cert.NotBefore)) / float64(total)
+	return math.Max(0, math.Min(100, elapsed))
+}
+
+// hasValidity reports whether the certificate carries a validity period,
+// which partial certificates do not.
+func hasValidity(cert *x509.Certificate) bool {
+	return !cert.NotBefore.IsZero() && !cert.NotAfter.IsZero()
+}
+
+// checkValidityPeriod returns an error if the certificate is valid for longer
+// than the specified number of days, e.g. the 398 days allowed by the
+// CA/Browser Forum.
+func checkValidityPeriod(cert *x509.Certificate, maxDays int) error {
+	period := cert.NotAfter.Sub(cert.NotBefore)
+	if period <= time.Duration(maxDays)*24*time.Hour {
+		return nil
+	}
+	return fmt.Errorf("%v: valid for %.1f days, at most %d allowed", errValidityTooLong, period.Hours()/24, maxDays)
+}
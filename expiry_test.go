@@ -0,0 +1,108 @@
+package main
+
+import (
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+func TestSoonestExpiry(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	soonest := soonestExpiry([]*x509.Certificate{issuer, cert})
+	if soonest != cert {
+		t.Errorf("expected %q, got %q", cert.Subject.CommonName, soonest.Subject.CommonName)
+	}
+}
+
+func TestSoonestExpiryIntermediate(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	// NOTE: an intermediate that expires before the leaf
+	intermediate := *issuer
+	intermediate.NotAfter = cert.NotAfter.Add(-time.Hour)
+
+	soonest := soonestExpiry([]*x509.Certificate{cert, &intermediate, nil})
+	if soonest != &intermediate {
+		t.Errorf("expected intermediate, got %q", soonest.Subject.CommonName)
+	}
+}
+
+func TestSoonestExpiryChain(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	root, _ := readCertificate("./testdata/DigiCertHighAssuranceEVRootCA.crt")
+	unrelated, _ := readCertificate("./testdata/DigiCertSHA2SecureServerCA.crt")
+
+	// NOTE: a root that expires before the leaf, and an unrelated certificate
+	// that expires even sooner
+	expiringRoot := *root
+	expiringRoot.NotAfter = cert.NotAfter.Add(-time.Hour)
+	expiringUnrelated := *unrelated
+	expiringUnrelated.NotAfter = cert.NotAfter.Add(-2 * time.Hour)
+
+	chain := issuerChain(cert, issuer, []*x509.Certificate{&expiringUnrelated, issuer, &expiringRoot})
+	if len(chain) != 3 {
+		t.Fatalf("expected leaf, issuer and root, got %d certificates", len(chain))
+	}
+
+	soonest := soonestExpiry(chain)
+	if soonest != &expiringRoot {
+		t.Errorf("expected root, got %q", soonest.Subject.CommonName)
+	}
+}
+
+func TestExpiryMessage(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	now := time.Date(2018, 7, 20, 12, 0, 0, 0, time.UTC)
+	expected := "twitter.com, expires at 2018-07-30 12:00:00 +0000 UTC (10 days remaining)"
+	if got := expiryMessage(cert, now); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	now = time.Date(2018, 8, 1, 12, 0, 0, 0, time.UTC)
+	expected = "twitter.com, expired at 2018-07-30 12:00:00 +0000 UTC (2 days ago)"
+	if got := expiryMessage(cert, now); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestValidityElapsed(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	for _, tc := range []struct {
+		now      time.Time
+		expected float64
+	}{
+		{cert.NotBefore, 0},
+		{cert.NotAfter, 100},
+		{cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 4), 25},
+		{cert.NotBefore.Add(-time.Hour), 0}, // not yet valid
+		{cert.NotAfter.Add(time.Hour), 100}, // expired
+	} {
+		if got := validityElapsed(cert, tc.now); got != tc.expected {
+			t.Errorf("%s: expected %v, got %v", tc.now, tc.expected, got)
+		}
+	}
+}
+
+func TestCheckValidityPeriod(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	if err := checkValidityPeriod(cert, 398); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+
+	err := checkValidityPeriod(cert, 365)
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := "validity period too long: valid for 370.5 days, at most 365 allowed"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
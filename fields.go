@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fields maps the names of the fields that can be selected with -fields to
+// functions returning their value.
+var fields = map[string]func(st *Status) string{
+	"serial": func(st *Status) string {
+		return st.SerialNumber.String()
+	},
+	"status": func(st *Status) string {
+		return strings.ToLower(st.Status)
+	},
+	"reason": func(st *Status) string {
+		if st.Reason == "" {
+			return ""
+		}
+		return revocationReasonNames[st.ReasonCode]
+	},
+	"revoked_at": func(st *Status) string {
+		return formatTime(st.RevokedAt)
+	},
+	"responder": func(st *Status) string {
+		return st.Responder
+	},
+	"produced_at": func(st *Status) string {
+		return formatTime(st.ProducedAt)
+	},
+	"this_update": func(st *Status) string {
+		return formatTime(st.ThisUpdate)
+	},
+	"next_update": func(st *Status) string {
+		return formatTime(st.NextUpdate)
+	},
+}
+
+// certFields maps the names of the fields that are derived from the
+// certificate itself, rather than from its status, to functions returning
+// their value.
+var certFields = map[string]func(cert *x509.Certificate, now time.Time) string{
+	"validity_elapsed": func(cert *x509.Certificate, now time.Time) string {
+		if !hasValidity(cert) {
+			return ""
+		}
+		return strconv.FormatFloat(validityElapsed(cert, now), 'f', 1, 64)
+	},
+}
+
+// formatTime formats the time as RFC 3339, or returns an empty string if it
+// is not set.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseFields parses a comma-separated list of field names, e.g.
+// 'serial,status,next_update'.
+func parseFields(list string) ([]string, error) {
+	names := strings.Split(list, ",")
+
+	for i, name := range names {
+		names[i] = strings.TrimSpace(name)
+		_, ok := fields[names[i]]
+		if _, isCert := certFields[names[i]]; !ok && !isCert {
+			return nil, fmt.Errorf("%v: %q", errUnknownField, name)
+		}
+	}
+
+	return names, nil
+}
+
+// printFields prints the selected fields of the status and certificate on a
+// single line, in key=value form.
+func printFields(st *Status, cert *x509.Certificate, now time.Time, names []string) {
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		if field, ok := certFields[name]; ok {
+			pairs[i] = name + "=" + field(cert, now)
+			continue
+		}
+		pairs[i] = name + "=" + fields[name](st)
+	}
+
+	fmt.Fprintln(out, strings.Join(pairs, " "))
+}
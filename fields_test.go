@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestPrintFields(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+
+	out = new(bytes.Buffer) // capture output
+
+	names, err := parseFields("serial,status,reason,revoked_at,next_update")
+	if err != nil {
+		t.Fatal(err)
+	}
+	printFields(statusFromResponse(resp), &x509.Certificate{}, time.Now(), names)
+
+	expected := "serial=582831098329266023459877175593458587837818271346 " +
+		"status=revoked " +
+		"reason=keyCompromise " +
+		"revoked_at=2017-06-18T17:57:00Z " +
+		"next_update=2017-12-25T16:24:32Z\n"
+
+	got := out.(*bytes.Buffer).String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestPrintFieldsGood(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+
+	out = new(bytes.Buffer) // capture output
+
+	printFields(statusFromResponse(resp), &x509.Certificate{}, time.Now(), []string{"status", "reason"})
+
+	expected := "status=good reason=\n"
+
+	got := out.(*bytes.Buffer).String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestPrintFieldsValidityElapsed(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	out = new(bytes.Buffer) // capture output
+
+	names, err := parseFields("status,validity_elapsed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	printFields(statusFromResponse(resp), cert, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), names)
+
+	expected := "status=good validity_elapsed=43.2\n"
+
+	got := out.(*bytes.Buffer).String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestParseFieldsUnknown(t *testing.T) {
+	_, err := parseFields("serial,expiry")
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := `unknown field: "expiry"`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
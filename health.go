@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// health is the word written to the -health-file once the status is known.
+var health = "error"
+
+// writeHealth writes the health word for the exit code to the health file. It
+// is replaced by startHealth.
+var writeHealth = func(code int) {}
+
+// healthWord returns the single-word health of the status: 'good', 'revoked',
+// or 'error' when the status is neither.
+func healthWord(st *Status) string {
+	switch st.Status {
+	case statusMessage(ocsp.Good):
+		return "good"
+	case statusMessage(ocsp.Revoked):
+		return "revoked"
+	default:
+		return "error"
+	}
+}
+
+// healthFailed reports whether the -health-file records an error for a status
+// that was found, such as an unknown one, in which case certstatus exits with
+// 1, so that systemd sees the same result as the file holds.
+func healthFailed() bool {
+	return *healthPath != "" && health == "error"
+}
+
+// startHealth arranges for the health to be written to the file at path once
+// writeHealth is called. Errors always write 'error', regardless of the
+// status found before.
+func startHealth(path string) {
+	var once sync.Once
+	writeHealth = func(code int) {
+		once.Do(func() {
+			word := health
+			if code == 1 {
+				word = "error"
+			}
+
+			if err := ioutil.WriteFile(path, []byte(word+"\n"), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestHealthWord(t *testing.T) {
+	for status, expected := range map[string]string{
+		"Good":          "good",
+		"Revoked":       "revoked",
+		"Unknown":       "error",
+		"Server failed": "error",
+	} {
+		if got := healthWord(&Status{Status: status}); got != expected {
+			t.Errorf("%s: expected %q, got %q", status, expected, got)
+		}
+	}
+}
+
+func TestHealthFailed(t *testing.T) {
+	defer func() {
+		*healthPath = ""
+		health = "error"
+	}()
+
+	health = healthWord(&Status{Status: "Unknown"})
+	if healthFailed() {
+		t.Error("did not expect a failure without -health-file")
+	}
+
+	*healthPath = "health"
+	if !healthFailed() {
+		t.Error("expected an unknown status to fail with -health-file")
+	}
+
+	health = healthWord(&Status{Status: "Good"})
+	if healthFailed() {
+		t.Error("did not expect a good status to fail")
+	}
+}
+
+func TestStartHealth(t *testing.T) {
+	dir := t.TempDir()
+	defer func() {
+		health = "error"
+		writeHealth = func(code int) {}
+	}()
+
+	for _, tc := range []struct {
+		health   string
+		code     int
+		expected string
+	}{
+		{"revoked", exitRevoked, "revoked\n"},
+		{"good", exitStatusChanged, "good\n"},
+		{"good", 1, "error\n"}, // failed after the status was known
+	} {
+		path := filepath.Join(dir, "health")
+
+		health = tc.health
+		startHealth(path)
+		writeHealth(tc.code)
+		writeHealth(0) // writes only once
+
+		got, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != tc.expected {
+			t.Errorf("exit code %d: expected %q, got %q", tc.code, tc.expected, got)
+		}
+	}
+}
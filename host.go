@@ -0,0 +1,79 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// certificateExtensions are the file extensions that mark an argument as a
+// certificate file, rather than a host name without a port.
+var certificateExtensions = map[string]bool{
+	".pem": true,
+	".crt": true,
+	".cer": true,
+	".der": true,
+}
+
+// hostAddress returns the address to connect to when the argument is a
+// host:port, or a host name without a port, in which case the port defaults
+// to 443. It returns false when the argument looks like a file path instead.
+func hostAddress(arg string) (string, bool) {
+	if arg == "" || strings.ContainsAny(arg, `/\ `) {
+		return "", false
+	}
+
+	host, port, err := net.SplitHostPort(arg)
+	if err != nil {
+		if certificateExtensions[strings.ToLower(filepath.Ext(arg))] {
+			return "", false
+		}
+		return net.JoinHostPort(arg, "443"), true
+	}
+
+	if n, err := strconv.Atoi(port); host == "" || err != nil || n <= 0 || n > 65535 {
+		return "", false
+	}
+	return arg, true
+}
+
+// readHostCertificates connects to the TLS server at addr, and returns the
+// certificate chain it serves, leaf first. The chain is not verified, so that
+// the status of invalid certificates can still be checked. The -resolve
+// overrides apply.
+func readHostCertificates(addr string) ([]*x509.Certificate, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	dialAddr := addr
+	if ip, ok := resolveOverrides[strings.ToLower(host)]; ok {
+		explain("connecting to %s for %s", ip, host)
+		dialAddr = net.JoinHostPort(ip, port)
+	}
+
+	dialer := &net.Dialer{Timeout: 30 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", dialAddr, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true, // inspect the certificate, not the connection
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToConnect
+	}
+	defer conn.Close()
+
+	certs := conn.ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, errNoCertificate
+	}
+
+	return certs, nil
+}
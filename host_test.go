@@ -0,0 +1,52 @@
+package main
+
+import (
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHostAddress(t *testing.T) {
+	for arg, expected := range map[string]string{
+		"example.com":      "example.com:443",
+		"example.com:8443": "example.com:8443",
+		"127.0.0.1:443":    "127.0.0.1:443",
+		"[::1]:443":        "[::1]:443",
+	} {
+		addr, ok := hostAddress(arg)
+		if !ok || addr != expected {
+			t.Errorf("%s: expected %q, got %q", arg, expected, addr)
+		}
+	}
+
+	for _, arg := range []string{
+		"./testdata/twitter.pem",
+		"certificate.pem",
+		"issuer.CRT",
+		"example.com:https",
+		":443",
+		"",
+	} {
+		if addr, ok := hostAddress(arg); ok {
+			t.Errorf("%s: expected file path, got address %q", arg, addr)
+		}
+	}
+}
+
+func TestReadHostCertificates(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0) // closed before the request
+	server.StartTLS()
+	defer server.Close()
+
+	certs, err := readHostCertificates(server.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 1 || !certs[0].Equal(server.Certificate()) {
+		t.Errorf("expected the server certificate, got %d certificates", len(certs))
+	}
+}
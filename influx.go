@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// influxTagEscaper escapes tag keys, tag values and field keys, as defined by
+// the InfluxDB line protocol.
+var influxTagEscaper = strings.NewReplacer(`,`, `\,`, `=`, `\=`, ` `, `\ `)
+
+// influxLine returns the status as a single InfluxDB line protocol record,
+// with the status as tags, and its times as fields holding Unix timestamps.
+func influxLine(st *Status, cert *x509.Certificate, now time.Time) string {
+	tags := []string{
+		"serial=" + influxTagEscaper.Replace(st.SerialNumber.String()),
+		"status=" + influxTagEscaper.Replace(fields["status"](st)),
+	}
+	if reason := fields["reason"](st); reason != "" {
+		tags = append(tags, "reason="+influxTagEscaper.Replace(reason))
+	}
+	if st.Responder != "" {
+		tags = append(tags, "responder="+influxTagEscaper.Replace(st.Responder))
+	}
+
+	var values []string
+	for _, field := range []struct {
+		key string
+		t   time.Time
+	}{
+		{"revoked_at", st.RevokedAt},
+		{"produced_at", st.ProducedAt},
+		{"this_update", st.ThisUpdate},
+		{"next_update", st.NextUpdate},
+	} {
+		if !field.t.IsZero() {
+			values = append(values, fmt.Sprintf("%s=%di", field.key, field.t.Unix()))
+		}
+	}
+	if !cert.NotAfter.IsZero() {
+		values = append(values, fmt.Sprintf("expiry_days=%di", daysRemaining(cert, now)))
+	}
+	if hasValidity(cert) {
+		values = append(values, "validity_elapsed="+certFields["validity_elapsed"](cert, now))
+	}
+
+	return "certstatus," + strings.Join(tags, ",") + " " + strings.Join(values, ",") +
+		" " + strconv.FormatInt(now.UnixNano(), 10)
+}
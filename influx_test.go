@@ -0,0 +1,48 @@
+package main
+
+import (
+	"crypto/x509"
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestInfluxLine(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+	cert, _ := readCertificate("./testdata/cisco_revoked.pem")
+
+	st := statusFromResponse(resp)
+	st.Responder = "http://ocsp.quovadisglobal.com"
+
+	now := time.Date(2017, 12, 24, 0, 0, 0, 0, time.UTC)
+
+	expected := "certstatus,serial=582831098329266023459877175593458587837818271346," +
+		"status=revoked,reason=keyCompromise,responder=http://ocsp.quovadisglobal.com " +
+		"revoked_at=1497808620i,produced_at=1514046272i,this_update=1514046272i,next_update=1514219072i,expiry_days=327i,validity_elapsed=55.1 " +
+		"1514073600000000000"
+
+	got := influxLine(st, cert, now)
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestInfluxLineEscaping(t *testing.T) {
+	st := &Status{
+		SerialNumber: big.NewInt(228),
+		Status:       "Good",
+		Responder:    "http://ocsp.example.com/a b,c=d",
+		ThisUpdate:   time.Unix(1514764800, 0),
+	}
+
+	expected := `certstatus,serial=228,status=good,responder=http://ocsp.example.com/a\ b\,c\=d ` +
+		"this_update=1514764800i 1514764800000000000"
+
+	got := influxLine(st, &x509.Certificate{}, time.Unix(1514764800, 0))
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
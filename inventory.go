@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// inventoryEntry is a single certificate in an inventory file. The inventory
+// file is a JSON array of these entries, where sha256 is the hex-encoded
+// SHA-256 thumbprint of the DER-encoded certificate, and certificate is the
+// PEM-encoded certificate itself.
+type inventoryEntry struct {
+	SHA256      string `json:"sha256"`
+	Certificate string `json:"certificate"`
+}
+
+// fingerprint returns the hex-encoded SHA-256 thumbprint of the certificate.
+func fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeThumbprint lowercases the thumbprint and strips any colons, so
+// that both 'AB:CD:..' and 'abcd..' forms are accepted.
+func normalizeThumbprint(thumbprint string) string {
+	return strings.ToLower(strings.Replace(thumbprint, ":", "", -1))
+}
+
+func readInventory(path string) ([]inventoryEntry, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadInventory
+	}
+
+	var entries []inventoryEntry
+	if err := json.Unmarshal(in, &entries); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadInventory
+	}
+
+	return entries, nil
+}
+
+// lookupCertificate returns the certificate with the specified SHA-256
+// thumbprint from the inventory at path.
+func lookupCertificate(path string, thumbprint string) (*x509.Certificate, error) {
+	entries, err := readInventory(path)
+	if err != nil {
+		return nil, err
+	}
+
+	thumbprint = normalizeThumbprint(thumbprint)
+
+	for _, entry := range entries {
+		if normalizeThumbprint(entry.SHA256) != thumbprint {
+			continue
+		}
+
+		cert, err := certificateFromBytes([]byte(entry.Certificate))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			return nil, errFailedToReadCertificate
+		}
+
+		// Do not trust the thumbprint recorded in the inventory blindly.
+		if fingerprint(cert) != thumbprint {
+			continue
+		}
+
+		return cert, nil
+	}
+
+	return nil, errThumbprintNotFound
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestLookupCertificate(t *testing.T) {
+	thumbprint := "AB:AC:B4:75:83:B9:E1:42:D9:0C:5F:C4:44:F8:58:0A:08:CF:A1:21:A0:9C:24:C5:AE:C1:37:17:10:90:C1:8E"
+
+	cert, err := lookupCertificate("./testdata/inventory.json", thumbprint)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "twitter.com"
+	if cert.Subject.CommonName != expected {
+		t.Errorf("expected %q, got %q", expected, cert.Subject.CommonName)
+	}
+}
+
+func TestLookupCertificateNotFound(t *testing.T) {
+	_, err := lookupCertificate("./testdata/inventory.json", "deadbeef")
+	if err != errThumbprintNotFound {
+		t.Errorf("expected %q, got %q", errThumbprintNotFound, err)
+	}
+}
+
+func TestLookupCertificateThumbprintMismatch(t *testing.T) {
+	// NOTE: this entry holds a certificate that does not match its thumbprint
+	thumbprint := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	_, err := lookupCertificate("./testdata/inventory.json", thumbprint)
+	if err != errThumbprintNotFound {
+		t.Errorf("expected %q, got %q", errThumbprintNotFound, err)
+	}
+}
+
+func TestLookupCertificateNoInventory(t *testing.T) {
+	_, err := lookupCertificate("./testdata/nonexistent.json", "deadbeef")
+	if err != errFailedToReadInventory {
+		t.Errorf("expected %q, got %q", errFailedToReadInventory, err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"golang.org/x/crypto/ocsp"
+)
+
+// jsonStatus is the status printed with -json. The responder, produced at
+// time and signature details are only present for OCSP, and whether the
+// certificate is listed only for CRLs.
+type jsonStatus struct {
+	SerialNumber     string `json:"serial_number"`
+	Status           string `json:"status"`
+	RevocationReason string `json:"revocation_reason,omitempty"`
+	RevokedAt        string `json:"revoked_at,omitempty"`
+	Listed           *bool  `json:"listed,omitempty"`
+	Responder        string `json:"responder,omitempty"`
+	ProducedAt       string `json:"produced_at,omitempty"`
+	ThisUpdate       string `json:"this_update,omitempty"`
+	NextUpdate       string `json:"next_update,omitempty"`
+
+	SignatureAlgorithm string `json:"signature_algorithm,omitempty"`
+	SignerKey          string `json:"signer_key,omitempty"`
+}
+
+// jsonStatusValue returns the status as 'good', 'revoked' or 'unknown', so
+// that consumers only need to handle these values. Statuses other than good
+// or revoked, such as a responder that failed, are reported as unknown.
+func jsonStatusValue(st *Status) string {
+	switch st.Status {
+	case statusMessage(ocsp.Good):
+		return "good"
+	case statusMessage(ocsp.Revoked):
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
+
+// statusJSON returns the status as a single JSON object, with times formatted
+// as RFC 3339. When crl is set, the object reports whether the certificate
+// is listed on the CRL.
+func statusJSON(st *Status, crl bool) (string, error) {
+	js := jsonStatus{
+		SerialNumber:     st.SerialNumber.String(),
+		Status:           jsonStatusValue(st),
+		RevocationReason: fields["reason"](st),
+		RevokedAt:        formatTime(st.RevokedAt),
+		Responder:        st.Responder,
+		ProducedAt:       formatTime(st.ProducedAt),
+		ThisUpdate:       formatTime(st.ThisUpdate),
+		NextUpdate:       formatTime(st.NextUpdate),
+
+		SignerKey: st.SignerKey,
+	}
+
+	if st.SignatureAlgorithm != x509.UnknownSignatureAlgorithm {
+		js.SignatureAlgorithm = st.SignatureAlgorithm.String()
+	}
+
+	if crl {
+		listed := st.Status == statusMessage(ocsp.Revoked)
+		js.Listed = &listed
+	}
+
+	data, err := json.Marshal(js)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
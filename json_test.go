@@ -0,0 +1,66 @@
+package main
+
+import (
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestStatusJSON(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+
+	st := statusFromResponse(resp)
+	st.Responder = "http://ocsp.quovadisglobal.com"
+
+	got, err := statusJSON(st, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"serial_number":"582831098329266023459877175593458587837818271346",` +
+		`"status":"revoked","revocation_reason":"keyCompromise","revoked_at":"2017-06-18T17:57:00Z",` +
+		`"responder":"http://ocsp.quovadisglobal.com","produced_at":"2017-12-23T16:24:32Z",` +
+		`"this_update":"2017-12-23T16:24:32Z","next_update":"2017-12-25T16:24:32Z",` +
+		`"signature_algorithm":"SHA1-RSA"}`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestStatusJSONCRL(t *testing.T) {
+	st := &Status{
+		SerialNumber: big.NewInt(219),
+		Status:       "Good",
+		ThisUpdate:   time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	got, err := statusJSON(st, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"serial_number":"219","status":"good","listed":false,"this_update":"2018-01-01T00:00:00Z"}`
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestJSONStatusValue(t *testing.T) {
+	for _, tc := range []struct {
+		code     int
+		expected string
+	}{
+		{ocsp.Good, "good"},
+		{ocsp.Revoked, "revoked"},
+		{ocsp.Unknown, "unknown"},
+		{ocsp.ServerFailed, "unknown"},
+	} {
+		st := &Status{Status: statusMessage(tc.code)}
+		if got := jsonStatusValue(st); got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", st.Status, tc.expected, got)
+		}
+	}
+}
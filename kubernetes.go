@@ -0,0 +1,66 @@
+//go:build kubernetes
+// +build kubernetes
+
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// readSecretCertificates returns the certificate chain held by the tls.crt
+// key of the specified kubernetes.io/tls secret, leaf first. When kubeconfig
+// or namespace are empty, the defaults used by kubectl apply.
+func readSecretCertificates(kubeconfig string, namespace string, name string) ([]*x509.Certificate, error) {
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		rules.ExplicitPath = kubeconfig
+	}
+
+	overrides := &clientcmd.ConfigOverrides{}
+	if namespace != "" {
+		overrides.Context.Namespace = namespace
+	}
+
+	config := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides)
+
+	namespace, _, err := config.Namespace()
+	if err != nil {
+		return nil, err
+	}
+
+	restConfig, err := config.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(context.Background(), name, metav1.GetOptions{})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadSecret
+	}
+
+	if secret.Type != corev1.SecretTypeTLS {
+		return nil, errNoTLSSecret
+	}
+
+	certs, err := certificatesFromBytes(secret.Data[corev1.TLSCertKey])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadCertificate
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,14 @@
+//go:build !kubernetes
+// +build !kubernetes
+
+package main
+
+import (
+	"crypto/x509"
+)
+
+// readSecretCertificates is a stub for builds without the kubernetes build
+// tag.
+func readSecretCertificates(kubeconfig string, namespace string, name string) ([]*x509.Certificate, error) {
+	return nil, errKubernetesNotSupported
+}
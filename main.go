@@ -1,29 +1,118 @@
 package main
 
 import (
+	"bytes"
 	"crypto/x509"
 	"encoding/pem"
 	"errors"
 	"flag"
 	"fmt"
+	"golang.org/x/crypto/ocsp"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 )
 
 var (
+	errCRLIssuerMismatch            = errors.New("CRL is not issued by the certificate issuer")
+	errConflictingOutputFlags       = errors.New("only one of -fields, -influx, -json and -ct-record can be set")
+	errFailedToConnect              = errors.New("failed to connect to TLS server")
 	errFailedToFetchOCSPResponse    = errors.New("failed to fetch OCSP response")
 	errFailedToGetResource          = errors.New("failed to get resource")
+	errFailedToLoadPKCS11Module     = errors.New("failed to load PKCS#11 module")
+	errFailedToReadAllowedSerials   = errors.New("failed to read allowed serials")
 	errFailedToReadCertificate      = errors.New("failed to read certificate")
+	errFailedToReadInventory        = errors.New("failed to read inventory")
+	errFailedToReadJWT              = errors.New("failed to read JWT")
+	errFailedToReadExtensions       = errors.New("failed to read request extensions")
+	errFailedToReadPolicyFile       = errors.New("failed to read policy file")
 	errFailedToReadResponseBody     = errors.New("failed to response body")
+	errFailedToReadSecret           = errors.New("failed to read secret")
+	errFailedToReadState            = errors.New("failed to read state file")
+	errInvalidAuthorityKeyID        = errors.New("invalid authority key identifier")
+	errInvalidCRLSignature          = errors.New("invalid CRL signature")
+	errInvalidRequestExtension      = errors.New("invalid request extension, expected OID:hexvalue")
+	errInvalidResolve               = errors.New("invalid resolve override, expected host:ip")
+	errInvalidSerialNumber          = errors.New("invalid serial number")
+	errInvalidX5CEntry              = errors.New("invalid x5c entry")
+	errFailedToWatchDir             = errors.New("failed to watch directory")
+	errFailedToWriteState           = errors.New("failed to write state file")
+	errKubernetesNotSupported       = errors.New("built without Kubernetes support")
 	errNoCertificate                = errors.New("no certificate")
 	errNoIssuerCertificate          = errors.New("no issuer certificate")
+	errNoIssuerInBundle             = errors.New("no issuer of the certificate in bundle")
+	errNoIssuerMatchingKeyID        = errors.New("no issuer matching the authority key identifier in bundle")
+	errNoSHA1AuthorityKeyID         = errors.New("no SHA-1 authority key identifier to identify the issuer by")
 	errNoOCSPServersFound           = errors.New("no OCSP servers found")
+	errNoX5CCertificates            = errors.New("no x5c certificates in JWT header")
+	errNoPolicyFile                 = errors.New("no policy file, set with -policy-file")
+	errNoPolicyRules                = errors.New("no rules set in policy file")
+	errNoRevocationEndpoints        = errors.New("no OCSP servers or CRL distribution points")
+	errNoTLSSecret                  = errors.New("secret is not of type kubernetes.io/tls")
+	errNoValidityPeriod             = errors.New("no validity period")
+	errPolicyNotPresent             = errors.New("certificate policy not present")
+	errNoTokenCertificate           = errors.New("no certificate with this label on token")
+	errPKCS11NotSupported           = errors.New("built without PKCS#11 support")
+	errThumbprintNotFound           = errors.New("thumbprint not found in inventory")
+	errSignatureAlgorithmNotAllowed = errors.New("signature algorithm not allowed")
+	errSerialNotAllowed             = errors.New("serial number not allowed")
+	errUnknownField                 = errors.New("unknown field")
+	errUnknownRevocationReason      = errors.New("unknown revocation reason")
+	errValidityTooLong              = errors.New("validity period too long")
+	errWatchNotSupported            = errors.New("built without fsnotify support, needed by watch-dir")
+	errHealthFileNotSupported       = errors.New("-health-file cannot be used with this command")
 	errNoCRLDistributionPointsFound = errors.New("no CRL distribution points found")
+	errNoValidCRL                   = errors.New("no valid CRL found")
+	errNoHTTPSEndpoints             = errors.New("no HTTPS endpoints found")
+	errUnhandledCriticalExtension   = errors.New("unhandled critical extension")
 
-	out    io.Writer  = os.Stdout // substituted during testing
-	client HTTPClient = &http.Client{}
+	out        io.Writer  = os.Stdout // substituted during testing
+	explainOut io.Writer  = os.Stderr // substituted during testing
+	client     HTTPClient = &http.Client{}
+
+	asn1Dump    = flag.Bool("asn1-dump", false, "print the ASN.1 structure of the OCSP response on stderr")
+	allowedPath = flag.String("allowed-serials", "", "fail unless the serial number of the certificate is listed in this file")
+	aki         = flag.String("aki", "", "check the serial number given in place of the certificate, issued by the CA with this authority key identifier")
+	bundle      = flag.String("bundle", "", "PEM file with the issuer candidates, used instead of fetching the issuer")
+	ctRecordOut = flag.Bool("ct-record", false, "print the serial, issuer, validity and status as compact JSON, for CT monitoring")
+	cpuProfile  = flag.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile  = flag.String("memprofile", "", "write a heap profile to this file")
+	maxValidity = flag.Int("max-validity", 0, "fail when the certificate is valid for longer than this many days (default off)")
+	ocspServer  = flag.String("ocsp-server", "", "use this OCSP server instead of the one listed in the certificate")
+	explainRun  = flag.Bool("explain", false, "explain the decisions taken during the check on stderr")
+	fast        = flag.Bool("fast", false, "do not fetch the issuer, nor verify the response against it, for a quick but unverified status")
+	fieldList   = flag.String("fields", "", "print only these comma-separated fields, in key=value form")
+	failReasons = flag.String("fail-reasons", "", "comma-separated revocation reasons that cause a non-zero exit status (default all)")
+	healthPath  = flag.String("health-file", "", "write good, revoked or error to this file, and print nothing to stdout")
+	influx      = flag.Bool("influx", false, "print the status as an InfluxDB line protocol record")
+	inventory   = flag.String("inventory", "", "look up the certificate by SHA-256 thumbprint in this inventory file")
+	jsonOut     = flag.Bool("json", false, "print the status as a JSON object")
+	kubeconfig  = flag.String("kubeconfig", "", "path to the kubeconfig file used with -k8s-secret")
+	k8sNS       = flag.String("k8s-namespace", "", "namespace of the Kubernetes secret")
+	k8sSecret   = flag.String("k8s-secret", "", "read the certificate from this kubernetes.io/tls secret")
+	policyPath  = flag.String("policy-file", "", "JSON file with the rules evaluated by policy-check")
+	pkcs11Lib   = flag.String("pkcs11-lib", "", "read the certificate from a token using this PKCS#11 module")
+	pkcs11PIN   = flag.String("pkcs11-pin", "", "PIN used to log in to the PKCS#11 token")
+	pkcs11Label = flag.String("pkcs11-label", "", "label of the certificate object on the PKCS#11 token")
+	reqHTTPS    = flag.Bool("require-https-endpoints", false, "only use HTTPS issuer, OCSP and CRL endpoints")
+	reqPolicy   = flag.String("require-policy", "", "fail unless the certificate asserts the policy with this OID")
+	reqExtPath  = flag.String("request-extensions", "", "add the extensions in this file, one OID:hexvalue per line, to the OCSP request")
+	sysIssuer   = flag.Bool("system-issuer", false, "look up the issuer in the system trust store before fetching it")
+	statePath   = flag.String("state-file", "", "record the status in this file, and report changes since the previous run")
+	tolerHold   = flag.Bool("tolerate-hold", false, "with -state-file, only warn when a certificate that was good has been put on hold")
+	x5c         = flag.Bool("x5c", false, "read the certificate chain from the x5c header of the JWT, or header JSON, in the file")
+	strictParse = flag.Bool("strict-parse", false, "reject certificates with unhandled critical extensions")
+)
+
+// Exit codes, besides 1 for errors. When the status changed since the previous
+// run, exitStatusChanged takes precedence over exitRevoked.
+const (
+	exitStatusChanged = 3
+	exitRevoked       = 4
+	exitPolicyFailed  = 5
 )
 
 // HTTPClient is an interface for fetching HTTP responses
@@ -34,53 +123,406 @@ type HTTPClient interface {
 
 func main() {
 	flag.Usage = func() {
-		fmt.Printf("usage: %s <command> <pem>\n", os.Args[0])
+		fmt.Printf("usage: %s [flags] <command> <pem|host:port|jwt|thumbprint|serial>\n", os.Args[0])
+		fmt.Printf("       %s [flags] watch-dir <command> <dir>\n", os.Args[0])
 		flag.PrintDefaults()
 	}
 
 	flag.Parse()
-	if flag.NArg() < 2 {
+	// NOTE: the certificate argument is omitted when reading from a token or
+	// a Kubernetes secret
+	needsArg := *pkcs11Lib == "" && *k8sSecret == ""
+	if flag.NArg() < 1 || flag.NArg() < 2 && needsArg {
 		flag.Usage()
-		os.Exit(1)
+		exit(1)
+	}
+
+	var formats int
+	for _, set := range []bool{*fieldList != "", *influx, *jsonOut, *ctRecordOut} {
+		if set {
+			formats++
+		}
+	}
+	if formats > 1 {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", errConflictingOutputFlags)
+		exit(1)
+	}
+
+	// NOTE: these commands do not find a revocation status to report
+	if *healthPath != "" && (flag.Arg(0) == "watch-dir" || flag.Arg(0) == "policy-check") {
+		fmt.Fprintf(os.Stderr, "[error] %v: %s\n", errHealthFileNotSupported, flag.Arg(0))
+		exit(1)
+	}
+
+	if *healthPath != "" {
+		startHealth(*healthPath)
+		defer writeHealth(0)
+		out = ioutil.Discard
+	}
+
+	if len(resolveOverrides) > 0 {
+		client = newResolvingClient(resolveOverrides)
+	}
+
+	if *cpuProfile != "" || *memProfile != "" {
+		if err := startProfiling(*cpuProfile, *memProfile); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		defer stopProfiling()
+	}
+
+	if flag.Arg(0) == "watch-dir" {
+		if flag.NArg() < 3 {
+			flag.Usage()
+			exit(1)
+		}
+
+		// NOTE: the checks are run with the flags given to watch-dir, except
+		// for those that apply to the watch-dir process itself
+		flags := checkFlags(os.Args[1 : len(os.Args)-flag.NArg()])
+		command, dir := flag.Arg(1), flag.Arg(2)
+
+		explain("watching %s for new certificates to check with %s", dir, command)
+		d := newDebouncer(watchDebounce, func(path string) {
+			checkFile(flags, command, path)
+		})
+		if err := watchDir(dir, d.trigger); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		return
+	}
+
+	var failOn map[int]bool // nil means all reasons
+	if *failReasons != "" {
+		reasons, err := parseRevocationReasons(*failReasons)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		failOn = reasons
+	}
+
+	if *reqExtPath != "" {
+		exts, err := readRequestExtensions(*reqExtPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		requestExtensions = exts
+	}
+
+	var selected []string
+	if *fieldList != "" {
+		names, err := parseFields(*fieldList)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		selected = names
 	}
 
 	// TODO: move to method that returns both cert + issuer?
-	path := os.Args[2]
-	cert, err := readCertificate(path)
+	var cert, issuer *x509.Certificate
+	var chain []*x509.Certificate // issuer candidates supplied with cert
+	var err error
+
+	switch {
+	case *aki != "":
+		explain("looking up issuer with key identifier %s in bundle %s", *aki, *bundle)
+		cert, issuer, err = partialCertificate(flag.Arg(1), *aki, *bundle)
+	case *pkcs11Lib != "":
+		explain("reading certificate %q from PKCS#11 token", *pkcs11Label)
+		cert, err = readTokenCertificate(*pkcs11Lib, *pkcs11PIN, *pkcs11Label)
+	case *k8sSecret != "":
+		explain("reading certificate chain from Kubernetes secret %q", *k8sSecret)
+		chain, err = readSecretCertificates(*kubeconfig, *k8sNS, *k8sSecret)
+		if err == nil {
+			cert, chain = chain[0], chain[1:]
+		}
+	case *x5c:
+		explain("reading certificate chain from the x5c header in %s", flag.Arg(1))
+		chain, err = readX5CCertificates(flag.Arg(1))
+		if err == nil {
+			cert, chain = chain[0], chain[1:]
+		}
+	case *inventory != "":
+		explain("looking up thumbprint %s in inventory %s", flag.Arg(1), *inventory)
+		cert, err = lookupCertificate(*inventory, flag.Arg(1))
+	default:
+		// NOTE: files take precedence, so that existing paths keep working
+		addr, isHost := hostAddress(flag.Arg(1))
+		if _, statErr := os.Stat(flag.Arg(1)); isHost && os.IsNotExist(statErr) {
+			explain("reading certificate chain from TLS server %s", addr)
+			chain, err = readHostCertificates(addr)
+			if err == nil {
+				cert, chain = chain[0], chain[1:]
+			}
+			break
+		}
+
+		explain("reading certificate from %s", flag.Arg(1))
+		cert, err = readCertificate(flag.Arg(1))
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 
-	issuer, err := getIssuerCertificate(client, cert)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
-		os.Exit(1)
+	if *strictParse {
+		if err := checkCriticalExtensions(cert); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
 	}
 
-	switch os.Args[1] {
+	explain("certificate policies: %s", strings.Join(describePolicies(cert), ", "))
+
+	if *reqPolicy != "" {
+		if err := checkPolicy(cert, *reqPolicy); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+	}
+
+	if *allowedPath != "" {
+		serials, err := readAllowedSerials(*allowedPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+
+		if err := checkAllowedSerial(cert, serials); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+	}
+
+	if *maxValidity > 0 && hasValidity(cert) {
+		if err := checkValidityPeriod(cert, *maxValidity); err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+	}
+
+	explain("checking certificate %q with serial number %s", cert.Subject.CommonName, cert.SerialNumber)
+
+	// NOTE: the certificate is evaluated on its own, so no network calls are
+	// needed
+	if flag.Arg(0) == "policy-check" {
+		if *policyPath == "" {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", errNoPolicyFile)
+			exit(1)
+		}
+
+		rules, err := readPolicyFile(*policyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+
+		if !printVerdicts(rules.evaluate(cert)) {
+			exit(exitPolicyFailed)
+		}
+		return
+	}
+
+	if *ocspServer != "" {
+		cert.OCSPServer = []string{*ocspServer}
+	}
+
+	// NOTE: with -aki, the bundle has been searched by key identifier already
+	if *bundle != "" && *aki == "" {
+		candidates, err := readCertificates(*bundle)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+
+		issuer = findIssuer(cert, candidates)
+		if issuer == nil {
+			fmt.Fprintf(os.Stderr, "[error] %v: %s\n", errNoIssuerInBundle, *bundle)
+			exit(1)
+		}
+		chain = append(chain, candidates...)
+	}
+
+	if issuer == nil {
+		issuer = findIssuer(cert, chain)
+	}
+	if *fast {
+		fmt.Fprintf(os.Stderr, "[warning] -fast is set, so the status is NOT verified against the issuer\n")
+	}
+
+	if issuer != nil {
+		explain("using issuer %q supplied with the certificate", issuer.Subject.CommonName)
+	} else if *sysIssuer {
+		issuer = findSystemIssuer(cert)
+		if issuer != nil {
+			explain("using issuer %q from the system trust store", issuer.Subject.CommonName)
+		} else {
+			explain("no issuer found in the system trust store")
+		}
+	}
+
+	switch {
+	case issuer != nil:
+	case *fast:
+		explain("not fetching the issuer, as -fast is set")
+	default:
+		issuer, err = getIssuerCertificate(client, cert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+	}
+
+	var st *Status
+
+	switch flag.Arg(0) {
 	case "ocsp":
-		resp, err := getOCSPResponse(client, cert, issuer)
+		server, err := getOCSPServer(cert)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
-			os.Exit(1)
+			exit(1)
+		}
+		explain("found OCSP server %s", server)
+
+		issuers := []*x509.Certificate{issuer}
+		if issuer != nil {
+			issuers = append(issuers, alternateIssuers(cert, issuer, chain)...)
+		}
+		resp, respIssuer, err := getAuthorizedOCSPResponse(client, server, cert, issuers)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		if respIssuer != issuer {
+			fmt.Fprintf(os.Stderr, "[warning] responder is not authorized for issuer %q, used alternate issuer with serial number %s\n",
+				issuer.Subject.CommonName, respIssuer.SerialNumber)
+		}
+		st = statusFromResponse(resp)
+		st.Responder = server
+		st.SignerKey = signerKey(resp, respIssuer)
+
+		if st.SignerKey != "" {
+			explain("response signed with %s by a %s key", st.SignatureAlgorithm, st.SignerKey)
+		} else {
+			explain("response signed with %s", st.SignatureAlgorithm)
+		}
+		if weakSignatureAlgorithm(st.SignatureAlgorithm) {
+			fmt.Fprintf(os.Stderr, "[warning] OCSP response is signed with weak algorithm %s\n", st.SignatureAlgorithm)
 		}
-		printStatusResponse(resp)
 
 	case "crl":
-		st, err := GetCRLResponse(client, cert)
+		crlIssuer := issuer
+		if *fast {
+			crlIssuer = nil // do not verify the CRL signature
+		}
+		st, err = GetCRLResponse(client, cert, crlIssuer)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
-			os.Exit(1)
+			exit(1)
 		}
-		fmt.Print(st.String())
 
 	default:
 		flag.PrintDefaults()
-		os.Exit(1)
+		exit(1)
+	}
+
+	health = healthWord(st)
+
+	switch {
+	case selected != nil:
+		printFields(st, cert, time.Now(), selected)
+	case *influx:
+		fmt.Fprintln(out, influxLine(st, cert, time.Now()))
+	case *jsonOut:
+		line, err := statusJSON(st, flag.Arg(0) == "crl")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintln(out, line)
+	case *ctRecordOut:
+		line, err := ctRecordLine(st, cert)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+		fmt.Fprintln(out, line)
+	default:
+		fmt.Fprint(out, st.String())
+
+		if soonest := soonestExpiry(issuerChain(cert, issuer, chain)); soonest != nil {
+			fmt.Fprintf(out, "\nSoonest expiry: %s\n", expiryMessage(soonest, time.Now()))
+		}
+
+		if hasValidity(cert) {
+			fmt.Fprintf(out, "Validity elapsed: %.0f%%\n", validityElapsed(cert, time.Now()))
+		}
+	}
+
+	var tolerated bool
+
+	if *statePath != "" {
+		prev, err := recordStatus(*statePath, cert, st)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			exit(1)
+		}
+
+		tolerated = *tolerHold && prev != nil && prev.heldSince(st)
+
+		if prev != nil && prev.changed(st) {
+			fmt.Fprintf(out, "\nStatus changed: %s\n", prev.describeChange(st))
+
+			if !tolerated {
+				exit(exitStatusChanged)
+			}
+		}
+		if tolerated {
+			fmt.Fprintf(os.Stderr, "[warning] certificate is on hold, which may be undone\n")
+		}
+	}
+
+	if !tolerated && st.Status == statusMessage(ocsp.Revoked) && (failOn == nil || failOn[st.ReasonCode]) {
+		exit(exitRevoked)
+	}
+
+	if healthFailed() {
+		exit(1)
+	}
+}
+
+// explain writes a line narrating a decision taken during the check, when
+// -explain is set.
+func explain(format string, a ...interface{}) {
+	if *explainRun {
+		fmt.Fprintf(explainOut, "[explain] "+format+"\n", a...)
 	}
 }
 
+// secureEndpoints returns only the HTTPS URLs when -require-https-endpoints is
+// set, logging the plaintext URLs that are skipped.
+func secureEndpoints(urls []string) []string {
+	if !*reqHTTPS {
+		return urls
+	}
+
+	var secure []string
+	for _, u := range urls {
+		if strings.HasPrefix(strings.ToLower(u), "https://") {
+			secure = append(secure, u)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "[warning] skipping plaintext endpoint %s\n", u)
+	}
+
+	return secure
+}
+
 func certificateFromBytes(bytes []byte) (*x509.Certificate, error) {
 	block, bytes := pem.Decode(bytes)
 
@@ -94,6 +536,64 @@ func certificateFromBytes(bytes []byte) (*x509.Certificate, error) {
 	return x509.ParseCertificate(bytes)
 }
 
+// certificatesFromBytes returns all certificates in the PEM-encoded input in
+// order, skipping blocks of other types, or the single certificate when the
+// input is DER-encoded.
+func certificatesFromBytes(in []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	var decoded bool
+
+	for {
+		var block *pem.Block
+		block, in = pem.Decode(in)
+		if block == nil {
+			break
+		}
+		decoded = true
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if !decoded {
+		cert, err := x509.ParseCertificate(in)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errNoCertificate
+	}
+
+	return certs, nil
+}
+
+// readCertificates returns all certificates in the file at path.
+func readCertificates(path string) ([]*x509.Certificate, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadCertificate
+	}
+
+	certs, err := certificatesFromBytes(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadCertificate
+	}
+
+	return certs, nil
+}
+
 func readCertificate(path string) (*x509.Certificate, error) {
 	var in []byte
 	var err error
@@ -116,41 +616,98 @@ func readCertificate(path string) (*x509.Certificate, error) {
 	return cert, nil
 }
 
-func getIssuerCertificate(client HTTPClient, cert *x509.Certificate) (*x509.Certificate, error) {
-	var (
-		issCert *x509.Certificate
-	)
+// checkCriticalExtensions returns an error listing the OIDs of the critical
+// extensions that were not handled when parsing the certificate.
+func checkCriticalExtensions(cert *x509.Certificate) error {
+	if len(cert.UnhandledCriticalExtensions) == 0 {
+		return nil
+	}
 
-	for _, url := range cert.IssuingCertificateURL {
-		resp, err := client.Get(url)
-		if err != nil {
+	oids := make([]string, len(cert.UnhandledCriticalExtensions))
+	for i, oid := range cert.UnhandledCriticalExtensions {
+		oids[i] = oid.String()
+	}
+
+	return fmt.Errorf("%v: %s", errUnhandledCriticalExtension, strings.Join(oids, ", "))
+}
+
+// findIssuer returns the candidate that issued the certificate, or nil if
+// none of them did.
+func findIssuer(cert *x509.Certificate, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if !bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
 			continue
 		}
 
-		if err != nil {
-			return nil, errFailedToGetResource
+		if err := cert.CheckSignatureFrom(candidate); err == nil {
+			return candidate
 		}
+	}
 
-		defer func() {
-			if cerr := resp.Body.Close(); err == nil {
-				err = cerr
-			}
-		}()
+	return nil
+}
 
-		in, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			return nil, errFailedToReadResponseBody
+// alternateIssuers returns the candidates other than issuer that carry the
+// subject the certificate was issued by, such as cross-signed variants of the
+// issuer.
+func alternateIssuers(cert *x509.Certificate, issuer *x509.Certificate, candidates []*x509.Certificate) []*x509.Certificate {
+	var alternates []*x509.Certificate
+
+	for _, candidate := range candidates {
+		if candidate.Equal(issuer) || !bytes.Equal(candidate.RawSubject, cert.RawIssuer) {
+			continue
 		}
+		alternates = append(alternates, candidate)
+	}
+
+	return alternates
+}
+
+// getIssuerCertificate fetches the issuer from the certificate's AIA URLs,
+// trying each in turn. When all of them fail, the returned error wraps
+// errNoIssuerCertificate along with the failure for each URL.
+func getIssuerCertificate(client HTTPClient, cert *x509.Certificate) (*x509.Certificate, error) {
+	urls := secureEndpoints(cert.IssuingCertificateURL)
+	if len(urls) == 0 && len(cert.IssuingCertificateURL) > 0 {
+		return nil, errNoHTTPSEndpoints
+	}
+
+	errs := []error{errNoIssuerCertificate}
 
-		issCert, err = certificateFromBytes(in)
+	for _, url := range urls {
+		issCert, err := fetchIssuerCertificate(client, url)
 		if err != nil {
-			return nil, errNoIssuerCertificate
+			explain("failed to fetch issuer from %s: %v", url, err)
+			errs = append(errs, fmt.Errorf("%s: %w", url, err))
+			continue
 		}
-		break
+
+		explain("fetched issuer %q from %s", issCert.Subject.CommonName, url)
+		return issCert, nil
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func fetchIssuerCertificate(client HTTPClient, url string) (*x509.Certificate, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errFailedToGetResource, err)
 	}
+	defer resp.Body.Close()
 
-	if issCert == nil {
-		return nil, errNoIssuerCertificate
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s", errFailedToGetResource, resp.Status)
+	}
+
+	in, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errFailedToReadResponseBody, err)
+	}
+
+	issCert, err := certificateFromBytes(in)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errFailedToReadCertificate, err)
 	}
 
 	return issCert, nil
@@ -2,6 +2,7 @@ package main
 
 import (
 	"bytes"
+	"crypto/x509"
 	"errors"
 	"io/ioutil"
 	"net/http"
@@ -17,15 +18,29 @@ type MockHTTPClient struct{}
 func (m *MockHTTPClient) Get(url2 string) (*http.Response, error) {
 	u, _ := url.Parse(url2)
 	p := filepath.Clean(u.Path)
-	dat, _ := ioutil.ReadFile("./testdata" + p)
+	dat, err := ioutil.ReadFile("./testdata" + p)
+	if err != nil {
+		response := &http.Response{
+			Status:     "404 Not Found",
+			StatusCode: http.StatusNotFound,
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}
+		return response, nil
+	}
 
 	response := &http.Response{
-		Body: ioutil.NopCloser(bytes.NewBuffer(dat)),
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Body:       ioutil.NopCloser(bytes.NewBuffer(dat)),
 	}
 	return response, nil
 }
 
 func (m *MockHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	if r.Method == "GET" {
+		return m.Get(r.URL.String())
+	}
+
 	if r.URL.String() == "http://ocsp.digicert.com" {
 		ocspResponseBytes, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
 		response := &http.Response{
@@ -56,6 +71,49 @@ func TestMainOCSP(t *testing.T) {
 	}
 }
 
+func TestMainOCSPResponder(t *testing.T) {
+	out = new(bytes.Buffer) // capture output
+
+	client = &MockHTTPClient{}
+	os.Args = []string{
+		"certstatus",
+		"ocsp",
+		"./testdata/twitter.pem",
+	}
+	main()
+
+	expected := "Responder: http://ocsp.digicert.com"
+
+	got := out.(*bytes.Buffer).String()
+	if !strings.Contains(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestMainOCSPJSON(t *testing.T) {
+	out = new(bytes.Buffer) // capture output
+	defer func() { *jsonOut = false }()
+
+	client = &MockHTTPClient{}
+	os.Args = []string{
+		"certstatus",
+		"-json",
+		"ocsp",
+		"./testdata/twitter.pem",
+	}
+	main()
+
+	got := out.(*bytes.Buffer).String()
+
+	expected := `{"serial_number":"16190166165489431910151563605275097819","status":"good",` +
+		`"responder":"http://ocsp.digicert.com","produced_at":"2017-12-23T06:30:33Z",` +
+		`"this_update":"2017-12-23T06:30:33Z","next_update":"2017-12-30T05:45:33Z",` +
+		`"signature_algorithm":"SHA256-RSA","signer_key":"RSA 2048"}` + "\n"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
 func TestGetIssuerCert(t *testing.T) {
 	cert, err := readCertificate("./testdata/certificate.pem")
 	if err != nil {
@@ -87,3 +145,214 @@ func TestCertificateFromBytesNoCertificate(t *testing.T) {
 		t.Fatal("should return error")
 	}
 }
+
+func TestCheckCriticalExtensions(t *testing.T) {
+	cert, err := readCertificate("./testdata/certificate.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkCriticalExtensions(cert); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+}
+
+func TestCheckCriticalExtensionsUnhandled(t *testing.T) {
+	cert, err := readCertificate("./testdata/critical_extension.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = checkCriticalExtensions(cert)
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := "unhandled critical extension: 1.3.6.1.4.1.55555.1"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestCertificatesFromBytes(t *testing.T) {
+	in, _ := ioutil.ReadFile("./testdata/twitter_chain.pem")
+	certs, err := certificatesFromBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(certs) != 3 {
+		t.Fatalf("expected 3 certificates, got %d", len(certs))
+	}
+
+	expected := "twitter.com"
+	if certs[0].Subject.CommonName != expected {
+		t.Errorf("expected %q, got %q", expected, certs[0].Subject.CommonName)
+	}
+}
+
+func TestCertificatesFromBytesNoCertificate(t *testing.T) {
+	in, _ := ioutil.ReadFile("./testdata/private_key.pem")
+	_, err := certificatesFromBytes(in)
+	if err != errNoCertificate {
+		t.Errorf("expected %q, got %q", errNoCertificate, err)
+	}
+}
+
+func TestFindIssuer(t *testing.T) {
+	in, _ := ioutil.ReadFile("./testdata/twitter_chain.pem")
+	certs, err := certificatesFromBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	issuer := findIssuer(certs[0], certs[1:])
+	if issuer == nil {
+		t.Fatal("expected to find issuer")
+	}
+
+	expected := "DigiCert SHA2 Extended Validation Server CA"
+	if issuer.Subject.CommonName != expected {
+		t.Errorf("expected %q, got %q", expected, issuer.Subject.CommonName)
+	}
+}
+
+func TestFindIssuerNoMatch(t *testing.T) {
+	in, _ := ioutil.ReadFile("./testdata/twitter_chain.pem")
+	certs, err := certificatesFromBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if issuer := findIssuer(certs[0], certs[1:2]); issuer != nil {
+		t.Errorf("did not expect to find issuer, got %q", issuer.Subject.CommonName)
+	}
+}
+
+func TestAlternateIssuers(t *testing.T) {
+	in, _ := ioutil.ReadFile("./testdata/twitter_chain.pem")
+	certs, err := certificatesFromBytes(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: a variant with the same subject stands in for a cross-signed issuer
+	issuer := certs[2]
+	variant := *issuer
+	variant.Raw = nil
+
+	alternates := alternateIssuers(certs[0], issuer, []*x509.Certificate{certs[1], issuer, &variant})
+	if len(alternates) != 1 || alternates[0] != &variant {
+		t.Errorf("expected only the variant, got %d alternates", len(alternates))
+	}
+}
+
+func TestGetIssuerCertRequireHTTPS(t *testing.T) {
+	*reqHTTPS = true
+	defer func() { *reqHTTPS = false }()
+
+	cert, err := readCertificate("./testdata/certificate.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &MockHTTPClient{}
+	_, err = getIssuerCertificate(client, cert)
+	if err != errNoHTTPSEndpoints {
+		t.Errorf("expected %q, got %q", errNoHTTPSEndpoints, err)
+	}
+}
+
+type FailingHTTPClient struct {
+	MockHTTPClient
+}
+
+func (m *FailingHTTPClient) Get(url string) (*http.Response, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestGetIssuerCertErrors(t *testing.T) {
+	cert := &x509.Certificate{
+		IssuingCertificateURL: []string{
+			"http://cacerts.example.com/policy.json",
+			"http://cacerts.example.com/private_key.pem",
+		},
+	}
+
+	client := &MockHTTPClient{}
+	_, err := getIssuerCertificate(client, cert)
+	if !errors.Is(err, errNoIssuerCertificate) {
+		t.Fatalf("expected %q, got %q", errNoIssuerCertificate, err)
+	}
+	if !errors.Is(err, errFailedToReadCertificate) {
+		t.Errorf("expected %q, got %q", errFailedToReadCertificate, err)
+	}
+
+	for _, url := range cert.IssuingCertificateURL {
+		if !strings.Contains(err.Error(), url+": failed to read certificate") {
+			t.Errorf("expected failure for %s, got %q", url, err)
+		}
+	}
+}
+
+func TestGetIssuerCertNotFound(t *testing.T) {
+	cert := &x509.Certificate{
+		IssuingCertificateURL: []string{"http://cacerts.example.com/missing.crt"},
+	}
+
+	client := &MockHTTPClient{}
+	_, err := getIssuerCertificate(client, cert)
+	if !errors.Is(err, errNoIssuerCertificate) || !errors.Is(err, errFailedToGetResource) {
+		t.Fatalf("expected %q and %q, got %q", errNoIssuerCertificate, errFailedToGetResource, err)
+	}
+
+	expected := "no issuer certificate\n" +
+		"http://cacerts.example.com/missing.crt: failed to get resource: 404 Not Found"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestGetIssuerCertGetFails(t *testing.T) {
+	cert, err := readCertificate("./testdata/certificate.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &FailingHTTPClient{}
+	_, err = getIssuerCertificate(client, cert)
+	if !errors.Is(err, errNoIssuerCertificate) || !errors.Is(err, errFailedToGetResource) {
+		t.Fatalf("expected %q and %q, got %q", errNoIssuerCertificate, errFailedToGetResource, err)
+	}
+
+	expected := "no issuer certificate\n" +
+		"http://cacerts.digicert.com/DigiCertSHA2SecureServerCA.crt: failed to get resource: connection refused"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestMainOCSPBundle(t *testing.T) {
+	out = new(bytes.Buffer) // capture output
+	defer func() {
+		*bundle = ""
+		client = &MockHTTPClient{}
+	}()
+
+	// NOTE: the issuer cannot be fetched, so it must be taken from the bundle
+	client = &FailingHTTPClient{}
+	os.Args = []string{
+		"certstatus",
+		"-bundle", "./testdata/twitter_chain.pem",
+		"ocsp",
+		"./testdata/twitter.pem",
+	}
+	main()
+
+	got := out.(*bytes.Buffer).String()
+
+	expected := "Status: Good"
+	if !strings.Contains(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -3,44 +3,103 @@ package main
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
 	"crypto/x509"
+	"encoding/asn1"
 	"fmt"
 	"golang.org/x/crypto/ocsp"
 	"io/ioutil"
 	"net/http"
-	"net/url"
+	"os"
+	"strings"
 )
 
+var oidExtensionOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// hasOCSPNoCheck reports whether the responder certificate carries the
+// id-pkix-ocsp-nocheck extension, which tells clients to trust it for its
+// lifetime without checking its own revocation status.
+func hasOCSPNoCheck(cert *x509.Certificate) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidExtensionOCSPNoCheck) {
+			return true
+		}
+	}
+	return false
+}
+
 func getOCSPServer(cert *x509.Certificate) (string, error) {
 	ocspServers := cert.OCSPServer
 	if len(ocspServers) == 0 {
 		return "", errNoOCSPServersFound
 	}
+
+	ocspServers = secureEndpoints(ocspServers)
+	if len(ocspServers) == 0 {
+		return "", errNoHTTPSEndpoints
+	}
 	return ocspServers[0], nil
 }
 
-func getOCSPResponse(client HTTPClient, cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
-	ocspServer, err := getOCSPServer(cert)
-	if err != nil {
-		return nil, err
+// createKeyIDRequest creates an OCSP request for the certificate without its
+// issuer, taking the issuer key hash from the authority key identifier. This
+// only holds when the CA derived its key identifier from the SHA-1 hash of its
+// public key (RFC 5280, section 4.2.1.2), as most do.
+func createKeyIDRequest(cert *x509.Certificate) ([]byte, error) {
+	if len(cert.AuthorityKeyId) != sha1.Size {
+		return nil, errNoSHA1AuthorityKeyID
 	}
 
-	options := ocsp.RequestOptions{Hash: crypto.SHA1}
-	request, err := ocsp.CreateRequest(cert, issuer, &options)
-	if err != nil {
-		return nil, err
+	nameHash := sha1.Sum(cert.RawIssuer)
+	req := &ocsp.Request{
+		HashAlgorithm:  crypto.SHA1,
+		IssuerNameHash: nameHash[:],
+		IssuerKeyHash:  cert.AuthorityKeyId,
+		SerialNumber:   cert.SerialNumber,
 	}
+	return req.Marshal()
+}
 
-	url, err := url.Parse(ocspServer)
-	if err != nil {
-		return nil, err
+// fetchOCSPResponse requests the OCSP response for the certificate from the
+// specified OCSP server. When issuer is nil, the request is created from the
+// authority key identifier, and the response is not verified against the
+// issuer. With -fast, the response is never verified against the issuer.
+func fetchOCSPResponse(client HTTPClient, ocspServer string, cert *x509.Certificate, issuer *x509.Certificate) (*ocsp.Response, error) {
+	var request []byte
+	var err error
+
+	if issuer != nil {
+		options := ocsp.RequestOptions{Hash: crypto.SHA1}
+		request, err = ocsp.CreateRequest(cert, issuer, &options)
+		if err != nil {
+			return nil, err
+		}
+		explain("built OCSP request with %s issuer name and key hashes", options.Hash)
+	} else {
+		request, err = createKeyIDRequest(cert)
+		if err != nil {
+			return nil, err
+		}
+		explain("built OCSP request from the authority key identifier, without the issuer")
+	}
+
+	if len(requestExtensions) > 0 {
+		request, err = addRequestExtensions(request, requestExtensions)
+		if err != nil {
+			return nil, err
+		}
+		explain("added %d extensions to the OCSP request", len(requestExtensions))
 	}
 
+	// NOTE: the request is sent to the full URL, as some responders are
+	// mounted at a path, and the Host header keeps any port
 	req, err := http.NewRequest("POST", ocspServer, bytes.NewBuffer(request))
 	if err != nil {
 		return nil, err
 	}
-	req.Host = url.Hostname()
 	req.Header.Set("content-type", "application/ocsp-request")
 
 	resp, err := client.Do(req)
@@ -58,26 +117,119 @@ func getOCSPResponse(client HTTPClient, cert *x509.Certificate, issuer *x509.Cer
 		return nil, err
 	}
 
+	if *asn1Dump {
+		// NOTE: dumped before parsing, so that nonconformant responses can be
+		// inspected too
+		if err := dumpASN1(explainOut, body); err != nil {
+			fmt.Fprintf(os.Stderr, "[warning] failed to dump OCSP response: %v\n", err)
+		}
+	}
+
+	if *fast {
+		issuer = nil
+	}
+
+	// NOTE: without the issuer, only the signature of a delegated responder
+	// is checked, against the certificate embedded in the response
 	parsedResponse, err := ocsp.ParseResponseForCert(body, cert, issuer)
 	if err != nil {
 		return nil, err
 	}
 
+	if issuer == nil {
+		explain("response is not verified against the issuer")
+	} else if parsedResponse.Certificate != nil {
+		explain("verified response signed by delegated responder %q, issued by %q",
+			parsedResponse.Certificate.Subject.CommonName, issuer.Subject.CommonName)
+		if hasOCSPNoCheck(parsedResponse.Certificate) {
+			explain("delegated responder carries id-pkix-ocsp-nocheck, so it is trusted without checking its own revocation status")
+		} else {
+			explain("delegated responder lacks id-pkix-ocsp-nocheck, so its own revocation status should be checked, which is not done")
+		}
+	} else {
+		explain("verified response signed by issuer %q", issuer.Subject.CommonName)
+	}
+	explain("status %s, produced at %s", statusMessage(parsedResponse.Status), parsedResponse.ProducedAt)
+
 	return parsedResponse, nil
 }
 
-func printStatusResponse(resp *ocsp.Response) {
-	fmt.Fprintf(out, "Serial number: %s\n\n", resp.SerialNumber)
-	fmt.Fprintf(out, "Status: %s\n", statusMessage(resp.Status))
+// getAuthorizedOCSPResponse requests the OCSP response from the specified OCSP
+// server using each of the issuer candidates in turn, until the responder
+// returns a response other than 'unauthorized'. It returns the response along
+// with the issuer used.
+func getAuthorizedOCSPResponse(client HTTPClient, ocspServer string, cert *x509.Certificate, issuers []*x509.Certificate) (*ocsp.Response, *x509.Certificate, error) {
+	var err error
+
+	for _, issuer := range issuers {
+		var resp *ocsp.Response
+		resp, err = fetchOCSPResponse(client, ocspServer, cert, issuer)
+
+		if rerr, ok := err.(ocsp.ResponseError); ok && rerr.Status == ocsp.Unauthorized && issuer != nil {
+			explain("responder is not authorized for issuer %q (serial number %s)", issuer.Subject.CommonName, issuer.SerialNumber)
+			continue
+		}
+
+		return resp, issuer, err
+	}
+
+	return nil, nil, err
+}
+
+// statusFromResponse returns the status held by the OCSP response.
+func statusFromResponse(resp *ocsp.Response) *Status {
+	st := &Status{
+		SerialNumber: resp.SerialNumber,
+		Status:       statusMessage(resp.Status),
+		ProducedAt:   resp.ProducedAt,
+		ThisUpdate:   resp.ThisUpdate,
+		NextUpdate:   resp.NextUpdate,
+
+		SignatureAlgorithm: resp.SignatureAlgorithm,
+	}
 
 	if resp.Status == ocsp.Revoked {
-		fmt.Fprintf(out, "Reason: %s\n", revocationReason(resp.RevocationReason))
-		fmt.Fprintf(out, "Revoked at: %s\n", resp.RevokedAt)
+		st.Reason = revocationReason(resp.RevocationReason)
+		st.ReasonCode = resp.RevocationReason
+		st.RevokedAt = resp.RevokedAt
 	}
 
-	fmt.Fprintf(out, "\nProduced at: %s\n", resp.ProducedAt)
-	fmt.Fprintf(out, "This update: %s\n", resp.ThisUpdate)
-	fmt.Fprintf(out, "Next update: %s\n", resp.NextUpdate)
+	return st
+}
+
+// signerKey describes the public key that signed the response, which is that
+// of the delegated responder if present, or else of the issuer, e.g.
+// 'RSA 2048'. It returns an empty string when the signer is not known.
+func signerKey(resp *ocsp.Response, issuer *x509.Certificate) string {
+	signer := resp.Certificate
+	if signer == nil {
+		signer = issuer
+	}
+	if signer == nil {
+		return ""
+	}
+
+	switch pub := signer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA %d", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		return fmt.Sprintf("ECDSA %s", pub.Curve.Params().Name)
+	case ed25519.PublicKey:
+		return "Ed25519"
+	default:
+		return signer.PublicKeyAlgorithm.String()
+	}
+}
+
+// weakSignatureAlgorithm reports whether the signature algorithm relies on a
+// hash function that is no longer collision resistant.
+func weakSignatureAlgorithm(alg x509.SignatureAlgorithm) bool {
+	switch alg {
+	case x509.MD2WithRSA, x509.MD5WithRSA, x509.SHA1WithRSA, x509.DSAWithSHA1, x509.ECDSAWithSHA1:
+		return true
+	default:
+		return false
+	}
 }
 
 var (
@@ -99,6 +251,19 @@ var (
 		ocsp.PrivilegeWithdrawn:   "Privilege withdrawn",
 		ocsp.AACompromise:         "AA compromise",
 	}
+	// NOTE: the names used in RFC 5280
+	revocationReasonNames = map[int]string{
+		ocsp.Unspecified:          "unspecified",
+		ocsp.KeyCompromise:        "keyCompromise",
+		ocsp.CACompromise:         "cACompromise",
+		ocsp.AffiliationChanged:   "affiliationChanged",
+		ocsp.Superseded:           "superseded",
+		ocsp.CessationOfOperation: "cessationOfOperation",
+		ocsp.CertificateHold:      "certificateHold",
+		ocsp.RemoveFromCRL:        "removeFromCRL",
+		ocsp.PrivilegeWithdrawn:   "privilegeWithdrawn",
+		ocsp.AACompromise:         "aACompromise",
+	}
 )
 
 func statusMessage(code int) string {
@@ -108,3 +273,29 @@ func statusMessage(code int) string {
 func revocationReason(code int) string {
 	return revocationReasonMessages[code]
 }
+
+// parseRevocationReasons parses a comma-separated list of revocation reasons
+// named as in RFC 5280, e.g. 'keyCompromise,cACompromise', into a set of
+// reason codes. Names are matched case-insensitively.
+func parseRevocationReasons(list string) (map[int]bool, error) {
+	reasons := make(map[int]bool)
+
+	for _, name := range strings.Split(list, ",") {
+		code, ok := revocationReasonCode(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("%v: %q", errUnknownRevocationReason, name)
+		}
+		reasons[code] = true
+	}
+
+	return reasons, nil
+}
+
+func revocationReasonCode(name string) (int, bool) {
+	for code, reasonName := range revocationReasonNames {
+		if strings.EqualFold(name, reasonName) {
+			return code, true
+		}
+	}
+	return 0, false
+}
@@ -2,12 +2,16 @@ package main
 
 import (
 	"bytes"
+	"crypto"
+	"crypto/x509"
+	"fmt"
 	"golang.org/x/crypto/ocsp"
 	"io/ioutil"
+	"net/http"
 	"testing"
 )
 
-func TestGetOCSPResponse(t *testing.T) {
+func TestFetchOCSPResponse(t *testing.T) {
 	cert, err := readCertificate("./testdata/twitter.pem")
 	if err != nil {
 		t.Fatal("Could not read test certificate.")
@@ -19,7 +23,7 @@ func TestGetOCSPResponse(t *testing.T) {
 	}
 
 	client := &MockHTTPClient{}
-	resp, _ := getOCSPResponse(client, cert, issuer)
+	resp, _ := fetchOCSPResponse(client, "http://ocsp.digicert.com", cert, issuer)
 
 	expected := "16190166165489431910151563605275097819"
 
@@ -48,7 +52,7 @@ func TestPrintStatusResponse(t *testing.T) {
 		"This update: 2017-12-23 06:30:33 +0000 UTC\n" +
 		"Next update: 2017-12-30 05:45:33 +0000 UTC\n"
 
-	printStatusResponse(resp)
+	fmt.Fprint(out, statusFromResponse(resp).String())
 
 	got := out.(*bytes.Buffer).String()
 	if got != expected {
@@ -70,7 +74,7 @@ func TestPrintStatusResponseRevoked(t *testing.T) {
 		"This update: 2017-12-23 16:24:32 +0000 UTC\n" +
 		"Next update: 2017-12-25 16:24:32 +0000 UTC\n"
 
-	printStatusResponse(resp)
+	fmt.Fprint(out, statusFromResponse(resp).String())
 
 	got := out.(*bytes.Buffer).String()
 	if got != expected {
@@ -95,3 +99,312 @@ func TestRevocationReason(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, reason)
 	}
 }
+
+func TestStatusFromResponseRevoked(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+
+	st := statusFromResponse(resp)
+
+	expected := "Serial number: 582831098329266023459877175593458587837818271346\n\n" +
+		"Status: Revoked\n" +
+		"Reason: Key compromise\n" +
+		"Revoked at: 2017-06-18 17:57:00 +0000 UTC\n\n" +
+		"Produced at: 2017-12-23 16:24:32 +0000 UTC\n" +
+		"This update: 2017-12-23 16:24:32 +0000 UTC\n" +
+		"Next update: 2017-12-25 16:24:32 +0000 UTC\n"
+
+	got := st.String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestParseRevocationReasons(t *testing.T) {
+	reasons, err := parseRevocationReasons("keyCompromise, CACompromise")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reasons[ocsp.KeyCompromise] || !reasons[ocsp.CACompromise] {
+		t.Errorf("expected key and CA compromise, got %v", reasons)
+	}
+
+	if reasons[ocsp.CertificateHold] {
+		t.Error("did not expect certificate hold")
+	}
+}
+
+func TestParseRevocationReasonsUnknown(t *testing.T) {
+	_, err := parseRevocationReasons("keyCompromise,onHold")
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := `unknown revocation reason: "onHold"`
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
+
+func TestFetchOCSPResponseExplain(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal("Could not read test certificate.")
+	}
+
+	issuer, err := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	if err != nil {
+		t.Fatal("Could not read test issuer certificate.")
+	}
+
+	*explainRun = true
+	defer func() { *explainRun = false }()
+	explainOut = new(bytes.Buffer) // capture output
+
+	client := &MockHTTPClient{}
+	if _, err := fetchOCSPResponse(client, "http://ocsp.digicert.com", cert, issuer); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[explain] built OCSP request with SHA-1 issuer name and key hashes\n" +
+		"[explain] verified response signed by issuer \"DigiCert SHA2 Extended Validation Server CA\"\n" +
+		"[explain] status Good, produced at 2017-12-23 06:30:33 +0000 UTC\n"
+
+	got := explainOut.(*bytes.Buffer).String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+// UnauthorizedOnceHTTPClient returns an 'unauthorized' OCSP response to the
+// first request, and defers to MockHTTPClient afterwards.
+type UnauthorizedOnceHTTPClient struct {
+	MockHTTPClient
+	requests int
+}
+
+func (m *UnauthorizedOnceHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	m.requests++
+	if m.requests == 1 {
+		response := &http.Response{
+			Body: ioutil.NopCloser(bytes.NewBuffer(ocsp.UnauthorizedErrorResponse)),
+		}
+		return response, nil
+	}
+
+	return m.MockHTTPClient.Do(r)
+}
+
+// RecordingHTTPClient records the last request, and returns the OCSP response
+// served by MockHTTPClient regardless of the URL.
+type RecordingHTTPClient struct {
+	MockHTTPClient
+	request *http.Request
+}
+
+func (m *RecordingHTTPClient) Do(r *http.Request) (*http.Response, error) {
+	m.request = r
+
+	ocspResponseBytes, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
+	response := &http.Response{
+		Body: ioutil.NopCloser(bytes.NewBuffer(ocspResponseBytes)),
+	}
+	return response, nil
+}
+
+func TestFetchOCSPResponsePath(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	server := "http://ocsp.example.com:8080/ocsp/responder?profile=tls"
+
+	client := &RecordingHTTPClient{}
+	if _, err := fetchOCSPResponse(client, server, cert, issuer); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := client.request.URL.String(); got != server {
+		t.Errorf("expected request to %q, got %q", server, got)
+	}
+
+	expected := "ocsp.example.com:8080"
+	if client.request.Host != expected {
+		t.Errorf("expected host %q, got %q", expected, client.request.Host)
+	}
+}
+
+func TestGetAuthorizedOCSPResponse(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal("Could not read test certificate.")
+	}
+
+	other, err := readCertificate("./testdata/DigiCertSHA2SecureServerCA.crt")
+	if err != nil {
+		t.Fatal("Could not read test issuer certificate.")
+	}
+
+	issuer, err := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	if err != nil {
+		t.Fatal("Could not read test issuer certificate.")
+	}
+
+	client := &UnauthorizedOnceHTTPClient{}
+	resp, respIssuer, err := getAuthorizedOCSPResponse(client, "http://ocsp.digicert.com", cert, []*x509.Certificate{other, issuer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if respIssuer != issuer {
+		t.Errorf("expected issuer %q, got %q", issuer.Subject.CommonName, respIssuer.Subject.CommonName)
+	}
+
+	expected := "16190166165489431910151563605275097819"
+	if resp.SerialNumber.String() != expected {
+		t.Errorf("expected %q, got %q", expected, resp.SerialNumber.String())
+	}
+}
+
+func TestGetAuthorizedOCSPResponseUnauthorized(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal("Could not read test certificate.")
+	}
+
+	issuer, err := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+	if err != nil {
+		t.Fatal("Could not read test issuer certificate.")
+	}
+
+	client := &UnauthorizedOnceHTTPClient{}
+	_, _, err = getAuthorizedOCSPResponse(client, "http://ocsp.digicert.com", cert, []*x509.Certificate{issuer})
+
+	expected := ocsp.ResponseError{Status: ocsp.Unauthorized}
+	if err != expected {
+		t.Errorf("expected %q, got %q", expected, err)
+	}
+}
+
+func TestHasOCSPNoCheck(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/cisco_ocsp_response_revoked.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+
+	if !hasOCSPNoCheck(resp.Certificate) {
+		t.Error("expected delegated responder to carry id-pkix-ocsp-nocheck")
+	}
+
+	cert, _ := readCertificate("./testdata/certificate.pem")
+	if hasOCSPNoCheck(cert) {
+		t.Error("did not expect certificate to carry id-pkix-ocsp-nocheck")
+	}
+}
+
+func TestGetOCSPServerRequireHTTPS(t *testing.T) {
+	*reqHTTPS = true
+	defer func() { *reqHTTPS = false }()
+
+	cert := &x509.Certificate{
+		OCSPServer: []string{"http://ocsp.example.com", "https://ocsp.example.com"},
+	}
+
+	server, err := getOCSPServer(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "https://ocsp.example.com"
+	if server != expected {
+		t.Errorf("expected %q, got %q", expected, server)
+	}
+}
+
+func TestGetOCSPServerRequireHTTPSNoneFound(t *testing.T) {
+	*reqHTTPS = true
+	defer func() { *reqHTTPS = false }()
+
+	cert, _ := readCertificate("./testdata/certificate.pem")
+	_, err := getOCSPServer(cert)
+	if err != errNoHTTPSEndpoints {
+		t.Errorf("expected %q, got %q", errNoHTTPSEndpoints, err)
+	}
+}
+
+func TestCreateKeyIDRequest(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	// NOTE: DigiCert derives its key identifiers from the SHA-1 hash of the
+	// public key, so the request must match the one created with the issuer
+	expected, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request, err := createKeyIDRequest(cert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(request, expected) {
+		t.Errorf("expected %x, got %x", expected, request)
+	}
+}
+
+func TestCreateKeyIDRequestNoAuthorityKeyID(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	cert.AuthorityKeyId = nil
+
+	_, err := createKeyIDRequest(cert)
+	if err != errNoSHA1AuthorityKeyID {
+		t.Errorf("expected %q, got %v", errNoSHA1AuthorityKeyID, err)
+	}
+}
+
+func TestFetchOCSPResponseFast(t *testing.T) {
+	*fast = true
+	defer func() { *fast = false }()
+
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	client := &MockHTTPClient{}
+	resp, err := fetchOCSPResponse(client, "http://ocsp.digicert.com", cert, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := ocsp.Good
+	if resp.Status != expected {
+		t.Errorf("expected %d, got %d", expected, resp.Status)
+	}
+}
+
+func TestSignerKey(t *testing.T) {
+	rawResp, _ := ioutil.ReadFile("./testdata/twitter_ocsp_response_v1.der")
+	resp, _ := ocsp.ParseResponse(rawResp, nil)
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	expected := "RSA 2048"
+	if got := signerKey(resp, issuer); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	if got := signerKey(resp, nil); got != "" {
+		t.Errorf("expected no signer key without issuer, got %q", got)
+	}
+}
+
+func TestWeakSignatureAlgorithm(t *testing.T) {
+	for alg, expected := range map[x509.SignatureAlgorithm]bool{
+		x509.SHA1WithRSA:     true,
+		x509.ECDSAWithSHA1:   true,
+		x509.MD5WithRSA:      true,
+		x509.SHA256WithRSA:   false,
+		x509.ECDSAWithSHA384: false,
+		x509.PureEd25519:     false,
+	} {
+		if got := weakSignatureAlgorithm(alg); got != expected {
+			t.Errorf("%s: expected %t, got %t", alg, expected, got)
+		}
+	}
+}
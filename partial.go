@@ -0,0 +1,83 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// parseHex decodes a hex string, optionally separated by colons, e.g.
+// '3D:D3:50:A5'.
+func parseHex(s string) ([]byte, error) {
+	return hex.DecodeString(strings.Replace(s, ":", "", -1))
+}
+
+// parseSerialNumber parses a serial number in decimal, or in hex when it is
+// prefixed with '0x' or separated by colons.
+func parseSerialNumber(s string) (*big.Int, error) {
+	serial := new(big.Int)
+
+	switch {
+	case strings.HasPrefix(strings.ToLower(s), "0x"):
+		if _, ok := serial.SetString(s[2:], 16); ok {
+			return serial, nil
+		}
+	case strings.Contains(s, ":"):
+		if b, err := parseHex(s); err == nil {
+			return serial.SetBytes(b), nil
+		}
+	default:
+		if _, ok := serial.SetString(s, 10); ok {
+			return serial, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%v: %q", errInvalidSerialNumber, s)
+}
+
+// findIssuerByKeyID returns the candidate whose subject key identifier
+// matches the authority key identifier, or nil if none does.
+func findIssuerByKeyID(aki []byte, candidates []*x509.Certificate) *x509.Certificate {
+	for _, candidate := range candidates {
+		if len(candidate.SubjectKeyId) > 0 && bytes.Equal(candidate.SubjectKeyId, aki) {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+// partialCertificate returns a certificate holding only the serial number and
+// authority key identifier, along with its issuer from the bundle at path.
+// This is all that is needed to build an OCSP request.
+func partialCertificate(serialNumber string, authorityKeyID string, path string) (*x509.Certificate, *x509.Certificate, error) {
+	serial, err := parseSerialNumber(serialNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aki, err := parseHex(authorityKeyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%v: %q", errInvalidAuthorityKeyID, authorityKeyID)
+	}
+
+	candidates, err := readCertificates(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	issuer := findIssuerByKeyID(aki, candidates)
+	if issuer == nil {
+		return nil, nil, errNoIssuerMatchingKeyID
+	}
+
+	cert := &x509.Certificate{
+		SerialNumber:   serial,
+		AuthorityKeyId: aki,
+	}
+
+	return cert, issuer, nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseSerialNumber(t *testing.T) {
+	expected := "16190166165489431910151563605275097819"
+
+	for _, s := range []string{
+		"16190166165489431910151563605275097819",
+		"0x0C2E1CD23118D9FD08E55A862B24BADB",
+		"0C:2E:1C:D2:31:18:D9:FD:08:E5:5A:86:2B:24:BA:DB",
+	} {
+		serial, err := parseSerialNumber(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if serial.String() != expected {
+			t.Errorf("expected %q, got %q", expected, serial.String())
+		}
+	}
+}
+
+func TestParseSerialNumberInvalid(t *testing.T) {
+	_, err := parseSerialNumber("0C2E1CD2")
+	if err == nil {
+		t.Fatal("should return error")
+	}
+}
+
+func TestPartialCertificate(t *testing.T) {
+	aki := "3D:D3:50:A5:D6:A0:AD:EE:F3:4A:60:0A:65:D3:21:D4:F8:F8:D6:0F"
+
+	cert, issuer, err := partialCertificate("0x0C2E1CD23118D9FD08E55A862B24BADB", aki, "./testdata/twitter_chain.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "DigiCert SHA2 Extended Validation Server CA"
+	if issuer.Subject.CommonName != expected {
+		t.Errorf("expected %q, got %q", expected, issuer.Subject.CommonName)
+	}
+
+	client := &MockHTTPClient{}
+	resp, err := fetchOCSPResponse(client, "http://ocsp.digicert.com", cert, issuer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "Good"
+	if statusMessage(resp.Status) != expected {
+		t.Errorf("expected %q, got %q", expected, statusMessage(resp.Status))
+	}
+}
+
+func TestPartialCertificateNoMatchingIssuer(t *testing.T) {
+	_, _, err := partialCertificate("1", "deadbeef", "./testdata/twitter_chain.pem")
+	if err != errNoIssuerMatchingKeyID {
+		t.Errorf("expected %q, got %q", errNoIssuerMatchingKeyID, err)
+	}
+}
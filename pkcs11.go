@@ -0,0 +1,95 @@
+//go:build pkcs11
+// +build pkcs11
+
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/miekg/pkcs11"
+)
+
+// readTokenCertificate reads the certificate object with the specified label
+// from the first PKCS#11 token that holds it. Only the certificate is read;
+// the private key never leaves the token.
+func readTokenCertificate(lib string, pin string, label string) (*x509.Certificate, error) {
+	p := pkcs11.New(lib)
+	if p == nil {
+		return nil, errFailedToLoadPKCS11Module
+	}
+	defer p.Destroy()
+
+	if err := p.Initialize(); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToLoadPKCS11Module
+	}
+	defer p.Finalize()
+
+	slots, err := p.GetSlotList(true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, slot := range slots {
+		der, err := findTokenCertificate(p, slot, pin, label)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[error] slot %d: %v\n", slot, err)
+			continue
+		}
+		if der == nil {
+			continue
+		}
+
+		return x509.ParseCertificate(der)
+	}
+
+	return nil, errNoTokenCertificate
+}
+
+// findTokenCertificate returns the DER-encoded value of the certificate
+// object with the specified label in the token in slot, or nil if there is no
+// such object.
+func findTokenCertificate(p *pkcs11.Ctx, slot uint, pin string, label string) ([]byte, error) {
+	session, err := p.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, err
+	}
+	defer p.CloseSession(session)
+
+	if pin != "" {
+		if err := p.Login(session, pkcs11.CKU_USER, pin); err != nil {
+			return nil, err
+		}
+		defer p.Logout(session)
+	}
+
+	template := []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_CERTIFICATE),
+		pkcs11.NewAttribute(pkcs11.CKA_LABEL, label),
+	}
+	if err := p.FindObjectsInit(session, template); err != nil {
+		return nil, err
+	}
+
+	objects, _, err := p.FindObjects(session, 1)
+	if ferr := p.FindObjectsFinal(session); err == nil {
+		err = ferr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(objects) == 0 {
+		return nil, nil
+	}
+
+	attrs, err := p.GetAttributeValue(session, objects[0], []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_VALUE, nil),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attrs[0].Value, nil
+}
@@ -0,0 +1,13 @@
+//go:build !pkcs11
+// +build !pkcs11
+
+package main
+
+import (
+	"crypto/x509"
+)
+
+// readTokenCertificate is a stub for builds without the pkcs11 build tag.
+func readTokenCertificate(lib string, pin string, label string) (*x509.Certificate, error) {
+	return nil, errPKCS11NotSupported
+}
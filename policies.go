@@ -0,0 +1,46 @@
+package main
+
+import (
+	"crypto/x509"
+	"fmt"
+)
+
+// policyNames maps the certificate policy OIDs defined by the CA/Browser
+// Forum to human readable names.
+var policyNames = map[string]string{
+	"2.5.29.32.0":    "Any policy",
+	"2.23.140.1.1":   "Extended validation",
+	"2.23.140.1.2.1": "Domain validated",
+	"2.23.140.1.2.2": "Organization validated",
+	"2.23.140.1.2.3": "Individual validated",
+	"2.23.140.1.3":   "Extended validation code signing",
+	"2.23.140.1.4.1": "Code signing",
+}
+
+// describePolicies returns the policy OIDs of the certificate, followed by
+// their name if known, e.g. '2.23.140.1.1 (Extended validation)'.
+func describePolicies(cert *x509.Certificate) []string {
+	policies := make([]string, len(cert.PolicyIdentifiers))
+	for i, oid := range cert.PolicyIdentifiers {
+		policies[i] = oid.String()
+		if name, ok := policyNames[oid.String()]; ok {
+			policies[i] += " (" + name + ")"
+		}
+	}
+	return policies
+}
+
+// checkPolicy returns an error if the certificate does not assert the policy
+// with the specified OID.
+func checkPolicy(cert *x509.Certificate, oid string) error {
+	for _, policy := range cert.PolicyIdentifiers {
+		if policy.String() == oid {
+			return nil
+		}
+	}
+
+	if name, ok := policyNames[oid]; ok {
+		return fmt.Errorf("%v: %s (%s)", errPolicyNotPresent, oid, name)
+	}
+	return fmt.Errorf("%v: %s", errPolicyNotPresent, oid)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDescribePolicies(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"2.16.840.1.114412.2.1",
+		"2.23.140.1.1 (Extended validation)",
+	}
+
+	got := describePolicies(cert)
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestCheckPolicy(t *testing.T) {
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := checkPolicy(cert, "2.23.140.1.1"); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+}
+
+func TestCheckPolicyNotPresent(t *testing.T) {
+	cert, err := readCertificate("./testdata/certificate.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = checkPolicy(cert, "2.23.140.1.1")
+	if err == nil {
+		t.Fatal("should return error")
+	}
+
+	expected := "certificate policy not present: 2.23.140.1.1 (Extended validation)"
+	if err.Error() != expected {
+		t.Errorf("expected %q, got %q", expected, err.Error())
+	}
+}
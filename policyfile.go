@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// policyFile is the ruleset read from the -policy-file, which policy-check
+// evaluates the certificate against. Rules that are not set are skipped.
+type policyFile struct {
+	MaxValidityDays            int      `json:"max_validity_days"`
+	RequiredPolicies           []string `json:"required_policies"`
+	AllowedSignatureAlgorithms []string `json:"allowed_signature_algorithms"`
+	RevocationCheckable        bool     `json:"revocation_checkable"`
+}
+
+// ruleVerdict is the outcome of evaluating a single rule, which passed when
+// err is nil.
+type ruleVerdict struct {
+	rule string
+	err  error
+}
+
+func (v ruleVerdict) String() string {
+	if v.err != nil {
+		return fmt.Sprintf("FAIL %s: %v", v.rule, v.err)
+	}
+	return "PASS " + v.rule
+}
+
+// readPolicyFile reads the ruleset in the JSON file at path. Unknown rules
+// are rejected, so that a misspelled rule does not pass silently, and so is
+// a ruleset without any rules set, which would pass every certificate.
+func readPolicyFile(path string) (*policyFile, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadPolicyFile
+	}
+
+	var rules policyFile
+
+	dec := json.NewDecoder(bytes.NewReader(in))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&rules); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadPolicyFile
+	}
+
+	if rules.empty() {
+		return nil, errNoPolicyRules
+	}
+
+	return &rules, nil
+}
+
+// empty reports whether none of the rules are set, in which case evaluate
+// returns no verdicts.
+func (p *policyFile) empty() bool {
+	return p.MaxValidityDays <= 0 && len(p.RequiredPolicies) == 0 &&
+		len(p.AllowedSignatureAlgorithms) == 0 && !p.RevocationCheckable
+}
+
+// evaluate returns the verdict for each rule that is set, in the order of the
+// policy file fields. Each required policy is a rule of its own.
+func (p *policyFile) evaluate(cert *x509.Certificate) []ruleVerdict {
+	var verdicts []ruleVerdict
+
+	// NOTE: partial certificates built for -aki carry no validity period,
+	// which would otherwise pass as a period of zero days
+	if p.MaxValidityDays > 0 {
+		err := errNoValidityPeriod
+		if hasValidity(cert) {
+			err = checkValidityPeriod(cert, p.MaxValidityDays)
+		}
+		verdicts = append(verdicts, ruleVerdict{"max_validity_days", err})
+	}
+
+	for _, oid := range p.RequiredPolicies {
+		verdicts = append(verdicts, ruleVerdict{"required_policies " + oid, checkPolicy(cert, oid)})
+	}
+
+	if len(p.AllowedSignatureAlgorithms) > 0 {
+		verdicts = append(verdicts, ruleVerdict{"allowed_signature_algorithms", checkSignatureAlgorithm(cert, p.AllowedSignatureAlgorithms)})
+	}
+
+	if p.RevocationCheckable {
+		verdicts = append(verdicts, ruleVerdict{"revocation_checkable", checkRevocationCheckable(cert)})
+	}
+
+	return verdicts
+}
+
+// checkSignatureAlgorithm returns an error unless the certificate is signed
+// with one of the allowed algorithms, named as by crypto/x509, e.g.
+// 'SHA256-RSA' or 'ECDSA-SHA384'.
+func checkSignatureAlgorithm(cert *x509.Certificate, allowed []string) error {
+	for _, name := range allowed {
+		if strings.EqualFold(name, cert.SignatureAlgorithm.String()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %s", errSignatureAlgorithmNotAllowed, cert.SignatureAlgorithm)
+}
+
+// checkRevocationCheckable returns an error unless the certificate lists an
+// OCSP server or CRL distribution point, so that its status can be checked.
+func checkRevocationCheckable(cert *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 && len(cert.CRLDistributionPoints) == 0 {
+		return errNoRevocationEndpoints
+	}
+	return nil
+}
+
+// printVerdicts prints the verdict for each rule, followed by the overall
+// result, and reports whether all rules passed.
+func printVerdicts(verdicts []ruleVerdict) bool {
+	passed := true
+	for _, v := range verdicts {
+		fmt.Fprintln(out, v)
+		if v.err != nil {
+			passed = false
+		}
+	}
+
+	if passed {
+		fmt.Fprintf(out, "\nResult: PASS (%d rules)\n", len(verdicts))
+	} else {
+		fmt.Fprintf(out, "\nResult: FAIL (%d rules)\n", len(verdicts))
+	}
+	return passed
+}
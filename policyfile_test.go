@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestPolicyFileEvaluate(t *testing.T) {
+	rules, err := readPolicyFile("./testdata/policy.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	out = new(bytes.Buffer) // capture output
+
+	if printVerdicts(rules.evaluate(cert)) {
+		t.Error("expected the check to fail")
+	}
+
+	expected := "PASS max_validity_days\n" +
+		"PASS required_policies 2.23.140.1.1\n" +
+		"FAIL required_policies 2.23.140.1.2.2: certificate policy not present: 2.23.140.1.2.2 (Organization validated)\n" +
+		"PASS allowed_signature_algorithms\n" +
+		"PASS revocation_checkable\n" +
+		"\nResult: FAIL (5 rules)\n"
+
+	got := out.(*bytes.Buffer).String()
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestPolicyFileEvaluatePass(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	rules := &policyFile{RequiredPolicies: []string{"2.23.140.1.1"}}
+
+	out = new(bytes.Buffer) // capture output
+
+	if !printVerdicts(rules.evaluate(cert)) {
+		t.Errorf("expected the check to pass, got %q", out.(*bytes.Buffer).String())
+	}
+}
+
+func TestPolicyFileEvaluateNoValidity(t *testing.T) {
+	rules := &policyFile{MaxValidityDays: 398}
+
+	verdicts := rules.evaluate(&x509.Certificate{})
+	if len(verdicts) != 1 || verdicts[0].err != errNoValidityPeriod {
+		t.Errorf("expected %q, got %v", errNoValidityPeriod, verdicts)
+	}
+}
+
+func TestCheckSignatureAlgorithm(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	if err := checkSignatureAlgorithm(cert, []string{"sha256-rsa"}); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+
+	err := checkSignatureAlgorithm(cert, []string{"ECDSA-SHA256"})
+	expected := "signature algorithm not allowed: SHA256-RSA"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
+
+func TestCheckRevocationCheckable(t *testing.T) {
+	cert, _ := readCertificate("./testdata/cloudflare_origin_ca_rsa_root.crt")
+
+	if err := checkRevocationCheckable(cert); err != errNoRevocationEndpoints {
+		t.Errorf("expected %q, got %v", errNoRevocationEndpoints, err)
+	}
+}
+
+func TestReadPolicyFileUnknownRule(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := ioutil.WriteFile(path, []byte(`{"max_validty_days": 398}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readPolicyFile(path); err != errFailedToReadPolicyFile {
+		t.Errorf("expected %q, got %v", errFailedToReadPolicyFile, err)
+	}
+}
+
+func TestReadPolicyFileNoRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.json")
+	if err := ioutil.WriteFile(path, []byte(`{"required_policies": []}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := readPolicyFile(path); err != errNoPolicyRules {
+		t.Errorf("expected %q, got %v", errNoPolicyRules, err)
+	}
+}
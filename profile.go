@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// stopProfiling stops profiling and flushes the profiles. It is replaced by
+// startProfiling.
+var stopProfiling = func() {}
+
+// exit stops profiling and writes the health file before exiting with the
+// specified code, as deferred functions are not run by os.Exit.
+func exit(code int) {
+	stopProfiling()
+	writeHealth(code)
+	os.Exit(code)
+}
+
+// startProfiling starts writing a CPU profile to cpuPath, and arranges for a
+// heap profile to be written to memPath once stopProfiling is called. Either
+// path may be empty. Profiles are also flushed on SIGINT, until stopProfiling
+// is called.
+func startProfiling(cpuPath string, memPath string) error {
+	var cpuFile *os.File
+
+	if cpuPath != "" {
+		f, err := os.Create(cpuPath)
+		if err != nil {
+			return err
+		}
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return err
+		}
+		cpuFile = f
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	done := make(chan struct{})
+
+	var once sync.Once
+	stopProfiling = func() {
+		once.Do(func() {
+			signal.Stop(interrupt)
+			close(done)
+
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				cpuFile.Close()
+			}
+
+			if memPath != "" {
+				if err := writeHeapProfile(memPath); err != nil {
+					fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+				}
+			}
+		})
+	}
+
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			exit(130)
+		case <-done:
+		}
+	}()
+
+	return nil
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	runtime.GC() // get up-to-date statistics
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
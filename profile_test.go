@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStartProfiling(t *testing.T) {
+	dir := t.TempDir()
+	defer func() { stopProfiling = func() {} }()
+
+	cpuPath := filepath.Join(dir, "cpu.prof")
+	memPath := filepath.Join(dir, "mem.prof")
+
+	if err := startProfiling(cpuPath, memPath); err != nil {
+		t.Fatal(err)
+	}
+	stopProfiling()
+	stopProfiling() // flushes only once
+
+	for _, path := range []string{cpuPath, memPath} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Size() == 0 {
+			t.Errorf("expected profile %s to be written", path)
+		}
+	}
+}
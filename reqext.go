@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// requestExtensions holds the extensions read from the -request-extensions
+// file, which are added to every OCSP request.
+var requestExtensions []pkix.Extension
+
+// parseOID parses an object identifier in dotted form, e.g.
+// '1.3.6.1.5.5.7.48.1.4'.
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	parts := strings.Split(s, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+
+	oid := make(asn1.ObjectIdentifier, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid[i] = n
+	}
+
+	return oid, nil
+}
+
+// parseRequestExtension parses an extension in OID:hexvalue form, where the
+// value is the DER encoding of the extension value, optionally separated by
+// colons.
+func parseRequestExtension(s string) (pkix.Extension, error) {
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 {
+		return pkix.Extension{}, fmt.Errorf("%v: %q", errInvalidRequestExtension, s)
+	}
+
+	oid, err := parseOID(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("%v: %v", errInvalidRequestExtension, err)
+	}
+
+	value, err := parseHex(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return pkix.Extension{}, fmt.Errorf("%v: %q: %v", errInvalidRequestExtension, s, err)
+	}
+
+	return pkix.Extension{Id: oid, Value: value}, nil
+}
+
+// readRequestExtensions reads the extensions in the file, one per line in
+// OID:hexvalue form. Blank lines and lines starting with '#' are ignored.
+func readRequestExtensions(path string) ([]pkix.Extension, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadExtensions
+	}
+
+	var exts []pkix.Extension
+
+	scanner := bufio.NewScanner(bytes.NewReader(in))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		ext, err := parseRequestExtension(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		exts = append(exts, ext)
+	}
+
+	return exts, nil
+}
+
+// addRequestExtensions adds the extensions to the DER encoded OCSP request,
+// as the requestExtensions of its TBSRequest (RFC 6960, section 4.1.1). The
+// request must not carry extensions or a signature already, which holds for
+// the requests created by ocsp.CreateRequest.
+func addRequestExtensions(request []byte, exts []pkix.Extension) ([]byte, error) {
+	var ocspRequest, tbsRequest asn1.RawValue
+	if _, err := asn1.Unmarshal(request, &ocspRequest); err != nil {
+		return nil, err
+	}
+	if _, err := asn1.Unmarshal(ocspRequest.Bytes, &tbsRequest); err != nil {
+		return nil, err
+	}
+
+	encoded, err := asn1.MarshalWithParams(exts, "explicit,tag:2")
+	if err != nil {
+		return nil, err
+	}
+
+	tbsRequest = asn1.RawValue{
+		Tag:        asn1.TagSequence,
+		IsCompound: true,
+		Bytes:      append(append([]byte{}, tbsRequest.Bytes...), encoded...),
+	}
+	tbs, err := asn1.Marshal(tbsRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: tbs})
+}
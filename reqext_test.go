@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"golang.org/x/crypto/ocsp"
+	"testing"
+)
+
+func TestParseRequestExtension(t *testing.T) {
+	ext, err := parseRequestExtension("1.3.6.1.5.5.7.48.1.4:300b06092b0601050507300101")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 4}
+	if !ext.Id.Equal(expected) {
+		t.Errorf("expected %s, got %s", expected, ext.Id)
+	}
+
+	if len(ext.Value) != 13 || ext.Critical {
+		t.Errorf("expected non-critical 13 byte value, got %x", ext.Value)
+	}
+}
+
+func TestParseRequestExtensionInvalid(t *testing.T) {
+	for _, s := range []string{
+		"1.3.6.1.5.5.7.48.1.4",
+		"1.3.6.1.5.5.7.48.1.4:zz",
+		"1.3.six:3000",
+		"1:3000",
+	} {
+		if _, err := parseRequestExtension(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+func TestReadRequestExtensions(t *testing.T) {
+	exts, err := readRequestExtensions("./testdata/request_extensions.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(exts) != 2 {
+		t.Fatalf("expected 2 extensions, got %d", len(exts))
+	}
+
+	expected := asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 8}
+	if !exts[1].Id.Equal(expected) || len(exts[1].Value) != 17 {
+		t.Errorf("expected %s with 17 byte value, got %s with %x", expected, exts[1].Id, exts[1].Value)
+	}
+}
+
+func TestAddRequestExtensions(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+	issuer, _ := readCertificate("./testdata/DigiCertSHA2ExtendedValidationServerCA.crt")
+
+	request, err := ocsp.CreateRequest(cert, issuer, &ocsp.RequestOptions{Hash: crypto.SHA1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exts, _ := readRequestExtensions("./testdata/request_extensions.txt")
+
+	extended, err := addRequestExtensions(request, exts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// NOTE: the request itself must be left intact
+	req, err := ocsp.ParseRequest(extended)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.SerialNumber.Cmp(cert.SerialNumber) != 0 {
+		t.Errorf("expected serial number %s, got %s", cert.SerialNumber, req.SerialNumber)
+	}
+
+	var parsed struct {
+		TBSRequest struct {
+			RequestList asn1.RawValue
+			Extensions  []pkix.Extension `asn1:"explicit,tag:2"`
+		}
+	}
+	if _, err := asn1.Unmarshal(extended, &parsed); err != nil {
+		t.Fatal(err)
+	}
+
+	got := parsed.TBSRequest.Extensions
+	if len(got) != 2 || !got[0].Id.Equal(exts[0].Id) || !bytes.Equal(got[1].Value, exts[1].Value) {
+		t.Errorf("expected %v, got %v", exts, got)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// resolveFlag holds the -resolve overrides, mapping host names to the IP
+// addresses to connect to instead.
+type resolveFlag map[string]string
+
+var resolveOverrides = make(resolveFlag)
+
+func init() {
+	flag.Var(resolveOverrides, "resolve", "connect to this IP address for the host, as host:ip (repeatable)")
+}
+
+func (r resolveFlag) String() string {
+	pairs := make([]string, 0, len(r))
+	for host, ip := range r {
+		pairs = append(pairs, host+":"+ip)
+	}
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// Set parses a host:ip pair. The IP address may be an IPv6 address, as the
+// host name cannot contain a colon.
+func (r resolveFlag) Set(value string) error {
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || net.ParseIP(parts[1]) == nil {
+		return fmt.Errorf("%v: %q", errInvalidResolve, value)
+	}
+
+	r[strings.ToLower(parts[0])] = parts[1]
+	return nil
+}
+
+// newResolvingClient returns an HTTP client that connects to the IP address
+// from overrides for the hosts listed there. As only the dialed address is
+// replaced, the Host header and the TLS server name still use the original
+// host name.
+func newResolvingClient(overrides map[string]string) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			host, port, err := net.SplitHostPort(addr)
+			if err == nil {
+				if ip, ok := overrides[strings.ToLower(host)]; ok {
+					explain("connecting to %s for %s", ip, host)
+					addr = net.JoinHostPort(ip, port)
+				}
+			}
+			return dialer.DialContext(ctx, network, addr)
+		},
+	}
+
+	return &http.Client{Transport: transport}
+}
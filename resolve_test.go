@@ -0,0 +1,58 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestResolveFlagSet(t *testing.T) {
+	r := make(resolveFlag)
+
+	if err := r.Set("ocsp.example.com:192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Set("CRL.example.com:2001:db8::1"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "crl.example.com:2001:db8::1,ocsp.example.com:192.0.2.1"
+	if r.String() != expected {
+		t.Errorf("expected %q, got %q", expected, r.String())
+	}
+}
+
+func TestResolveFlagSetInvalid(t *testing.T) {
+	r := make(resolveFlag)
+
+	for _, value := range []string{"ocsp.example.com", ":192.0.2.1", "ocsp.example.com:example.org"} {
+		if err := r.Set(value); err == nil {
+			t.Errorf("expected error for %q", value)
+		}
+	}
+}
+
+func TestNewResolvingClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	u, _ := url.Parse(server.URL)
+
+	client := newResolvingClient(map[string]string{"ocsp.example.invalid": u.Hostname()})
+	resp, err := client.Get("http://ocsp.example.invalid:" + u.Port() + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+
+	expected := "ocsp.example.invalid:" + u.Port()
+	if string(body) != expected {
+		t.Errorf("expected %q, got %q", expected, string(body))
+	}
+}
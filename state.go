@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"golang.org/x/crypto/ocsp"
+	"io/ioutil"
+	"os"
+)
+
+// stateEntry holds the last known status of a certificate. The state file is
+// a JSON object of these entries, keyed by the SHA-256 fingerprint of the
+// certificate.
+type stateEntry struct {
+	SerialNumber string `json:"serial_number"`
+	Status       string `json:"status"`
+	Reason       string `json:"reason,omitempty"`
+	HeldFrom     string `json:"held_from,omitempty"`
+}
+
+// changed reports whether the status differs from the recorded one.
+func (e stateEntry) changed(st *Status) bool {
+	return e.Status != st.Status || e.Reason != st.Reason
+}
+
+func (e stateEntry) String() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s (%s)", e.Status, e.Reason)
+	}
+	return e.Status
+}
+
+// heldFrom returns the status the certificate had before it was put on hold,
+// or its recorded status when it is not on hold.
+func (e stateEntry) heldFrom() string {
+	if e.HeldFrom != "" {
+		return e.HeldFrom
+	}
+	return e.Status
+}
+
+// heldSince reports whether the certificate was good before it was put on
+// hold, and is on hold now. It holds for every check while the hold lasts,
+// not only for the first one.
+func (e stateEntry) heldSince(st *Status) bool {
+	return e.heldFrom() == statusMessage(ocsp.Good) && st.reversible()
+}
+
+// describeChange describes the change from the recorded status, noting
+// whether a revocation is permanent or can be undone.
+func (e stateEntry) describeChange(st *Status) string {
+	msg := fmt.Sprintf("%s -> %s", e, newStateEntry(st))
+
+	switch {
+	case st.reversible():
+		msg += ", which is reversible"
+	case st.Status == statusMessage(ocsp.Revoked):
+		msg += ", which is permanent"
+	}
+
+	return msg
+}
+
+func newStateEntry(st *Status) stateEntry {
+	return stateEntry{
+		SerialNumber: st.SerialNumber.String(),
+		Status:       st.Status,
+		Reason:       st.Reason,
+	}
+}
+
+func readState(path string) (map[string]stateEntry, error) {
+	state := make(map[string]stateEntry)
+
+	in, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return state, nil // first run
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadState
+	}
+
+	if err := json.Unmarshal(in, &state); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadState
+	}
+
+	return state, nil
+}
+
+func writeState(path string, state map[string]stateEntry) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return errFailedToWriteState
+	}
+
+	return nil
+}
+
+// stateKey returns the key of the certificate in the state file, which is its
+// fingerprint. Partial certificates built for -aki carry no DER encoding, so
+// they are keyed by their authority key identifier and serial number instead.
+func stateKey(cert *x509.Certificate) string {
+	if len(cert.Raw) == 0 {
+		return fmt.Sprintf("%x:%x", cert.AuthorityKeyId, cert.SerialNumber)
+	}
+	return fingerprint(cert)
+}
+
+// recordStatus stores the status of the certificate in the state file at
+// path, and returns the previously recorded status, or nil when the
+// certificate had not been seen before.
+func recordStatus(path string, cert *x509.Certificate, st *Status) (*stateEntry, error) {
+	state, err := readState(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := stateKey(cert)
+
+	var prev *stateEntry
+	if entry, ok := state[key]; ok {
+		prev = &entry
+	}
+
+	entry := newStateEntry(st)
+	if prev != nil && st.reversible() {
+		entry.HeldFrom = prev.heldFrom()
+	}
+	state[key] = entry
+
+	if err := writeState(path, state); err != nil {
+		return nil, err
+	}
+
+	return prev, nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"golang.org/x/crypto/ocsp"
+	"math/big"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecordStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good := &Status{SerialNumber: cert.SerialNumber, Status: "Good"}
+	prev, err := recordStatus(path, cert, good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev != nil {
+		t.Fatalf("expected no previous status, got %q", prev)
+	}
+
+	revoked := &Status{SerialNumber: cert.SerialNumber, Status: "Revoked", Reason: "Key compromise"}
+	prev, err = recordStatus(path, cert, revoked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev == nil || !prev.changed(revoked) {
+		t.Fatalf("expected status change, got %v", prev)
+	}
+
+	expected := "Good -> Revoked (Key compromise), which is permanent"
+	got := prev.describeChange(revoked)
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	prev, err = recordStatus(path, cert, revoked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev == nil || prev.changed(revoked) {
+		t.Errorf("expected unchanged status, got %v", prev)
+	}
+}
+
+func TestReadStateInvalid(t *testing.T) {
+	_, err := readState("./testdata/twitter.pem")
+	if err != errFailedToReadState {
+		t.Errorf("expected %q, got %q", errFailedToReadState, err)
+	}
+}
+
+func TestStateEntryChanged(t *testing.T) {
+	entry := stateEntry{SerialNumber: "1", Status: "Unknown"}
+	st := &Status{SerialNumber: big.NewInt(1), Status: "Good"}
+
+	if !entry.changed(st) {
+		t.Error("expected Unknown -> Good to be a change")
+	}
+}
+
+func TestStateEntryHeldSince(t *testing.T) {
+	entry := stateEntry{SerialNumber: "1", Status: "Good"}
+	held := &Status{
+		SerialNumber: big.NewInt(1),
+		Status:       "Revoked",
+		Reason:       "Certificate hold",
+		ReasonCode:   ocsp.CertificateHold,
+	}
+
+	if !entry.heldSince(held) {
+		t.Error("expected Good -> Certificate hold to be a hold")
+	}
+
+	expected := "Good -> Revoked (Certificate hold), which is reversible"
+	if got := entry.describeChange(held); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	revoked := &Status{
+		SerialNumber: big.NewInt(1),
+		Status:       "Revoked",
+		Reason:       "Key compromise",
+		ReasonCode:   ocsp.KeyCompromise,
+	}
+
+	if entry.heldSince(revoked) {
+		t.Error("did not expect Good -> Key compromise to be a hold")
+	}
+
+	entry = stateEntry{SerialNumber: "1", Status: "Unknown"}
+	if entry.heldSince(held) {
+		t.Error("did not expect Unknown -> Certificate hold to be tolerated")
+	}
+}
+
+func TestRecordStatusHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	cert, err := readCertificate("./testdata/twitter.pem")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good := &Status{SerialNumber: cert.SerialNumber, Status: "Good"}
+	if _, err := recordStatus(path, cert, good); err != nil {
+		t.Fatal(err)
+	}
+
+	held := &Status{
+		SerialNumber: cert.SerialNumber,
+		Status:       "Revoked",
+		Reason:       "Certificate hold",
+		ReasonCode:   ocsp.CertificateHold,
+	}
+
+	for run := 2; run <= 3; run++ {
+		prev, err := recordStatus(path, cert, held)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev == nil || !prev.heldSince(held) {
+			t.Errorf("run %d: expected the hold to be tolerated, got %v", run, prev)
+		}
+	}
+
+	removed := &Status{
+		SerialNumber: cert.SerialNumber,
+		Status:       "Revoked",
+		Reason:       "Remove from CRL",
+		ReasonCode:   ocsp.RemoveFromCRL,
+	}
+	if removed.reversible() {
+		t.Error("did not expect Remove from CRL to be reversible")
+	}
+}
+
+func TestRecordStatusPartial(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	aki := "3D:D3:50:A5:D6:A0:AD:EE:F3:4A:60:0A:65:D3:21:D4:F8:F8:D6:0F"
+
+	// NOTE: neither serial has been seen before, whatever its status
+	for _, tc := range []struct {
+		serial string
+		status string
+	}{
+		{"0x0C2E1CD23118D9FD08E55A862B24BADB", "Good"},
+		{"0x0C2E1CD23118D9FD08E55A862B24BADC", "Revoked"},
+	} {
+		cert, _, err := partialCertificate(tc.serial, aki, "./testdata/twitter_chain.pem")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		prev, err := recordStatus(path, cert, &Status{SerialNumber: cert.SerialNumber, Status: tc.status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if prev != nil {
+			t.Errorf("%s: expected no previous status, got %q", tc.serial, prev)
+		}
+	}
+}
@@ -2,17 +2,29 @@ package main
 
 import (
 	"bytes"
+	"crypto/x509"
 	"fmt"
+	"golang.org/x/crypto/ocsp"
 	"math/big"
 	"time"
 )
 
-// Status holds the (revocation) status for a certificate
+// Status holds the (revocation) status for a certificate, along with the
+// times of the OCSP response or CRL it was obtained from.
 type Status struct {
 	SerialNumber *big.Int
 	Status       string
 	Reason       string
+	ReasonCode   int
 	RevokedAt    time.Time
+
+	Responder  string    // OCSP only
+	ProducedAt time.Time // OCSP only
+	ThisUpdate time.Time
+	NextUpdate time.Time
+
+	SignatureAlgorithm x509.SignatureAlgorithm // OCSP only
+	SignerKey          string                  // OCSP only, e.g. 'RSA 2048'
 }
 
 func (s Status) String() string {
@@ -29,5 +41,34 @@ func (s Status) String() string {
 		buf.WriteString(fmt.Sprintf("Revoked at: %s\n", s.RevokedAt.String()))
 	}
 
+	if s.Responder != "" || !s.ThisUpdate.IsZero() {
+		buf.WriteString("\n")
+
+		if s.Responder != "" {
+			buf.WriteString(fmt.Sprintf("Responder: %s\n", s.Responder))
+		}
+
+		if !s.ProducedAt.IsZero() {
+			buf.WriteString(fmt.Sprintf("Produced at: %s\n", s.ProducedAt.String()))
+		}
+
+		if !s.ThisUpdate.IsZero() {
+			buf.WriteString(fmt.Sprintf("This update: %s\n", s.ThisUpdate.String()))
+		}
+
+		if !s.NextUpdate.IsZero() {
+			buf.WriteString(fmt.Sprintf("Next update: %s\n", s.NextUpdate.String()))
+		}
+	}
+
 	return buf.String()
 }
+
+// reversible reports whether the certificate was revoked for a reason that
+// can be undone, i.e. it was put on hold, rather than revoked permanently.
+func (s Status) reversible() bool {
+	if s.Status != statusMessage(ocsp.Revoked) {
+		return false
+	}
+	return s.ReasonCode == ocsp.CertificateHold
+}
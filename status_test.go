@@ -48,3 +48,43 @@ func TestStatusWithReasonString(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, got)
 	}
 }
+
+func TestStatusWithUpdatesString(t *testing.T) {
+	st := &Status{
+		SerialNumber: big.NewInt(219),
+		Status:       "Good",
+		ThisUpdate:   time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	got := st.String()
+
+	expected := "Serial number: 219\n\n" +
+		"Status: Good\n\n" +
+		"This update: 2018-01-01 00:00:00 +0000 UTC\n"
+
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestStatusWithResponderString(t *testing.T) {
+	st := &Status{
+		SerialNumber: big.NewInt(224),
+		Status:       "Good",
+		Responder:    "http://ocsp.example.com",
+		ProducedAt:   time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+		ThisUpdate:   time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	got := st.String()
+
+	expected := "Serial number: 224\n\n" +
+		"Status: Good\n\n" +
+		"Responder: http://ocsp.example.com\n" +
+		"Produced at: 2018-01-01 00:00:00 +0000 UTC\n" +
+		"This update: 2018-01-01 00:00:00 +0000 UTC\n"
+
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+)
+
+// systemCertFiles are the locations of the system trust store bundle on
+// common platforms. They are read directly, as the system pool returned by
+// x509.SystemCertPool does not expose its certificates.
+var systemCertFiles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",                // Debian, Ubuntu, Gentoo
+	"/etc/pki/tls/certs/ca-bundle.crt",                  // Fedora, RHEL 6
+	"/etc/ssl/ca-bundle.pem",                            // OpenSUSE
+	"/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem", // CentOS, RHEL 7
+	"/etc/ssl/cert.pem",                                 // Alpine, macOS, BSDs
+}
+
+// findSystemIssuer returns the issuer of the certificate from the system trust
+// store, or nil if it is not there, or the trust store cannot be read on this
+// platform. As with crypto/x509, SSL_CERT_FILE overrides the locations.
+func findSystemIssuer(cert *x509.Certificate) *x509.Certificate {
+	paths := systemCertFiles
+	if path := os.Getenv("SSL_CERT_FILE"); path != "" {
+		paths = []string{path}
+	}
+
+	return findIssuerInFiles(cert, paths)
+}
+
+// findIssuerInFiles returns the certificate from the bundles at paths whose
+// subject key identifier matches the authority key identifier of the
+// certificate, and that issued it. Bundles that cannot be read are skipped.
+func findIssuerInFiles(cert *x509.Certificate, paths []string) *x509.Certificate {
+	if len(cert.AuthorityKeyId) == 0 {
+		return nil
+	}
+
+	for _, path := range paths {
+		in, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		candidates, err := certificatesFromBytes(in)
+		if err != nil {
+			explain("skipping trust store %s: %v", path, err)
+			continue
+		}
+		explain("searching %d certificates in trust store %s", len(candidates), path)
+
+		// NOTE: cross-signed variants share the key identifier, so each
+		// candidate is verified
+		for _, candidate := range candidates {
+			if !bytes.Equal(candidate.SubjectKeyId, cert.AuthorityKeyId) {
+				continue
+			}
+			if issuer := findIssuer(cert, []*x509.Certificate{candidate}); issuer != nil {
+				return issuer
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestFindIssuerInFiles(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	issuer := findIssuerInFiles(cert, []string{"./testdata/missing.pem", "./testdata/twitter_chain.pem"})
+	if issuer == nil {
+		t.Fatal("expected issuer in bundle")
+	}
+
+	expected := "DigiCert SHA2 Extended Validation Server CA"
+	if issuer.Subject.CommonName != expected {
+		t.Errorf("expected %q, got %q", expected, issuer.Subject.CommonName)
+	}
+}
+
+func TestFindIssuerInFilesNotFound(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	if issuer := findIssuerInFiles(cert, []string{"./testdata/test_ca.pem"}); issuer != nil {
+		t.Errorf("expected no issuer, got %q", issuer.Subject.CommonName)
+	}
+}
+
+func TestFindSystemIssuer(t *testing.T) {
+	cert, _ := readCertificate("./testdata/twitter.pem")
+
+	t.Setenv("SSL_CERT_FILE", "./testdata/twitter_chain.pem")
+	if issuer := findSystemIssuer(cert); issuer == nil {
+		t.Error("expected issuer from SSL_CERT_FILE")
+	}
+}
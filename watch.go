@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// watchDebounce is how long a file must be left alone after it was created or
+// written to, before it is checked, so that a file written in several steps
+// is checked only once.
+const watchDebounce = 500 * time.Millisecond
+
+// debouncer calls fn for a key once trigger has not been called for it for
+// the delay.
+type debouncer struct {
+	mu     sync.Mutex
+	delay  time.Duration
+	timers map[string]*time.Timer
+	fn     func(key string)
+}
+
+func newDebouncer(delay time.Duration, fn func(key string)) *debouncer {
+	return &debouncer{
+		delay:  delay,
+		timers: make(map[string]*time.Timer),
+		fn:     fn,
+	}
+}
+
+func (d *debouncer) trigger(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if t, ok := d.timers[key]; ok {
+		t.Stop()
+	}
+
+	var t *time.Timer
+	t = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		if d.timers[key] == t {
+			delete(d.timers, key)
+		}
+		d.mu.Unlock()
+
+		d.fn(key)
+	})
+	d.timers[key] = t
+}
+
+// waitForCertificate tries to parse the certificate in the file up to the
+// specified number of attempts, as its writer may not have finished yet. It
+// returns the error of the last attempt.
+func waitForCertificate(path string, attempts int, delay time.Duration) error {
+	for i := 1; ; i++ {
+		in, err := ioutil.ReadFile(path)
+		if err == nil {
+			_, err = certificateFromBytes(in)
+		}
+
+		if err == nil || i >= attempts {
+			return err
+		}
+		time.Sleep(delay)
+	}
+}
+
+// processFlags are the flags that apply to the watch-dir process itself. They
+// are not passed on to the checks, which would otherwise overwrite the
+// profiles of the watch-dir process with their own.
+var processFlags = map[string]bool{
+	"cpuprofile": true,
+	"memprofile": true,
+}
+
+// checkFlags returns the flags without the processFlags and their values,
+// which may be given as '-flag value' or as '-flag=value'.
+func checkFlags(flags []string) []string {
+	var kept []string
+	for i := 0; i < len(flags); i++ {
+		name, _, hasValue := strings.Cut(strings.TrimLeft(flags[i], "-"), "=")
+		if !strings.HasPrefix(flags[i], "-") || !processFlags[name] {
+			kept = append(kept, flags[i])
+			continue
+		}
+		if !hasValue {
+			i++ // skip the value
+		}
+	}
+	return kept
+}
+
+// checkMu serializes the checks, so that the output of checks of files that
+// settle at the same time is not interleaved.
+var checkMu sync.Mutex
+
+// checkFile checks the certificate in the file by running certstatus again,
+// with the same flags and the specified command, so that each check runs
+// exactly as it would on its own. Its exit status is not an error, as the
+// status has been printed already.
+func checkFile(flags []string, command string, path string) {
+	if err := waitForCertificate(path, 5, 200*time.Millisecond); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %s: %v\n", path, err)
+		return
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return
+	}
+
+	checkMu.Lock()
+	defer checkMu.Unlock()
+
+	fmt.Fprintf(out, "==> %s <==\n", path)
+
+	cmd := exec.Command(self, append(append(append([]string{}, flags...), command), path)...)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); !ok {
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		}
+	}
+
+	fmt.Fprintln(out)
+}
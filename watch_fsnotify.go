@@ -0,0 +1,52 @@
+//go:build fsnotify
+// +build fsnotify
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDir calls changed with the path of each PEM file that is created or
+// written to in the directory, until the watcher fails.
+func watchDir(dir string, changed func(path string)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return errFailedToWatchDir
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op&(fsnotify.Create|fsnotify.Write) == 0 {
+				continue
+			}
+			if !strings.EqualFold(filepath.Ext(event.Name), ".pem") {
+				continue
+			}
+			explain("%s: %s", event.Op, event.Name)
+			changed(event.Name)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+			return errFailedToWatchDir
+		}
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !fsnotify
+// +build !fsnotify
+
+package main
+
+// watchDir is a stub for builds without the fsnotify build tag.
+func watchDir(dir string, changed func(path string)) error {
+	return errWatchNotSupported
+}
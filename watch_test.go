@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDebouncer(t *testing.T) {
+	var mu sync.Mutex
+	calls := make(map[string]int)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	d := newDebouncer(50*time.Millisecond, func(key string) {
+		mu.Lock()
+		calls[key]++
+		mu.Unlock()
+		wg.Done()
+	})
+
+	for i := 0; i < 3; i++ {
+		d.trigger("a.pem") // rapid writes
+	}
+	d.trigger("b.pem")
+	wg.Wait()
+
+	time.Sleep(100 * time.Millisecond) // no further calls
+
+	mu.Lock()
+	defer mu.Unlock()
+	if calls["a.pem"] != 1 || calls["b.pem"] != 1 {
+		t.Errorf("expected a single call per file, got %v", calls)
+	}
+}
+
+func TestWaitForCertificate(t *testing.T) {
+	if err := waitForCertificate("./testdata/twitter.pem", 1, 0); err != nil {
+		t.Errorf("expected no error, got %q", err)
+	}
+
+	dir := t.TempDir()
+
+	// NOTE: a certificate that is only partially written
+	in, _ := ioutil.ReadFile("./testdata/twitter.pem")
+	path := filepath.Join(dir, "partial.pem")
+	if err := ioutil.WriteFile(path, in[:len(in)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		ioutil.WriteFile(path, in, 0644)
+	}()
+
+	if err := waitForCertificate(path, 50, 10*time.Millisecond); err != nil {
+		t.Errorf("expected no error once written, got %q", err)
+	}
+
+	if err := ioutil.WriteFile(path, in[:len(in)/2], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := waitForCertificate(path, 2, time.Millisecond); err == nil {
+		t.Error("expected error for partial certificate")
+	}
+}
+
+func TestCheckFlags(t *testing.T) {
+	flags := []string{"-cpuprofile", "cpu.prof", "-fields", "serial,status", "-memprofile=mem.prof", "--cpuprofile=x", "-explain"}
+
+	expected := "-fields serial,status -explain"
+	if got := strings.Join(checkFlags(flags), " "); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// jwtHeader is the part of a JWS header (RFC 7515) that holds the certificate
+// chain of the signing key, leaf first, as base64-encoded DER.
+type jwtHeader struct {
+	X5C []string `json:"x5c"`
+}
+
+// decodeJWTHeader returns the header JSON of the token, which may be given as
+// a compact serialized JWT, or as the header JSON itself.
+func decodeJWTHeader(token []byte) ([]byte, error) {
+	token = bytes.TrimSpace(token)
+	if bytes.HasPrefix(token, []byte("{")) {
+		return token, nil
+	}
+
+	header := strings.SplitN(string(token), ".", 2)[0]
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(header, "="))
+}
+
+// readX5CCertificates returns the certificate chain held by the x5c header of
+// the JWT, or of the header JSON, in the file at path.
+func readX5CCertificates(path string) ([]*x509.Certificate, error) {
+	in, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadJWT
+	}
+
+	data, err := decodeJWTHeader(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadJWT
+	}
+
+	var header jwtHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		fmt.Fprintf(os.Stderr, "[error] %v\n", err)
+		return nil, errFailedToReadJWT
+	}
+
+	if len(header.X5C) == 0 {
+		return nil, errNoX5CCertificates
+	}
+
+	// NOTE: unlike the rest of the JWT, x5c entries use the standard base64
+	// alphabet, with padding
+	certs := make([]*x509.Certificate, len(header.X5C))
+	for i, entry := range header.X5C {
+		der, err := base64.StdEncoding.DecodeString(entry)
+		if err != nil {
+			return nil, fmt.Errorf("%v: entry %d: %v", errInvalidX5CEntry, i, err)
+		}
+
+		certs[i], err = x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("%v: entry %d: %v", errInvalidX5CEntry, i, err)
+		}
+	}
+
+	return certs, nil
+}
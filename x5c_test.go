@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadX5CCertificates(t *testing.T) {
+	for _, path := range []string{"./testdata/x5c.jwt", "./testdata/x5c_header.json"} {
+		certs, err := readX5CCertificates(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+
+		if len(certs) != 2 {
+			t.Fatalf("%s: expected 2 certificates, got %d", path, len(certs))
+		}
+
+		expected := "twitter.com"
+		if certs[0].Subject.CommonName != expected {
+			t.Errorf("%s: expected leaf %q, got %q", path, expected, certs[0].Subject.CommonName)
+		}
+
+		if findIssuer(certs[0], certs[1:]) == nil {
+			t.Errorf("%s: expected issuer among x5c certificates", path)
+		}
+	}
+}
+
+func TestReadX5CCertificatesInvalid(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, tc := range []struct {
+		header   string
+		expected error
+	}{
+		{`{"alg":"RS256"}`, errNoX5CCertificates},
+		{`{"x5c":["not base64!"]}`, errInvalidX5CEntry},
+		{`{"x5c":["bm90IGEgY2VydGlmaWNhdGU="]}`, errInvalidX5CEntry},
+		{`not.a.jwt`, errFailedToReadJWT},
+	} {
+		path := filepath.Join(dir, "token")
+		if err := ioutil.WriteFile(path, []byte(tc.header), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := readX5CCertificates(path)
+		if err == nil {
+			t.Fatalf("%s: should return error", tc.header)
+		}
+		if err != tc.expected && !strings.HasPrefix(err.Error(), tc.expected.Error()+": entry 0: ") {
+			t.Errorf("%s: expected %q, got %v", tc.header, tc.expected, err)
+		}
+	}
+}